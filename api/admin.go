@@ -0,0 +1,55 @@
+package api
+
+import "github.com/gin-gonic/gin"
+
+// GetAllKey godoc
+// @Summary Get All API Key
+// @ID get-all-key
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Key
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 503 {object} httputil.Problem
+// @Router /api/admin/key [get]
+func (ctr *Controller) GetAllKey(ctx *gin.Context) {
+	keys, err := ctr.DAO.GetKeys(ctx.Request.Context())
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	respond(ctx, keys, nil)
+}
+
+// GetBrokenLinks godoc
+// @Summary Get Broken Links
+// @Description Lists the book links found broken by the last link check.
+// @ID get-broken-links
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query string false "page number (default=1)" Format(string)
+// @Param per_page query string false "per_page link count (default=20, max=100)" Format(string)
+// @Success 200 {array} model.LinkCheck
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 503 {object} httputil.Problem
+// @Router /api/admin/broken-links [get]
+func (ctr *Controller) GetBrokenLinks(ctx *gin.Context) {
+	limit, offset, err := paginate(ctx)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	links, err := ctr.DAO.GetBrokenLinks(ctx.Request.Context(), limit, offset)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	respondPage(ctx, "admin/broken-links", limit, offset, links, nil)
+}
@@ -1,13 +1,26 @@
 package api
 
 import (
-	"errors"
+	"html/template"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/health"
 	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/images"
+	"github.com/kautsarady/adindopustaka/logging"
+	"github.com/kautsarady/adindopustaka/metrics"
 	"github.com/kautsarady/adindopustaka/model"
+	"github.com/kautsarady/adindopustaka/ratelimit"
+	"github.com/kautsarady/adindopustaka/storage"
+	"github.com/kautsarady/adindopustaka/tracing"
+	"github.com/kautsarady/adindopustaka/web"
 
 	// doc.json
 	_ "github.com/kautsarady/adindopustaka/docs"
@@ -18,72 +31,262 @@ import (
 // Controller .
 type Controller struct {
 	DAO    *model.DAO
+	Auth   *auth.Authenticator
 	Router *gin.Engine
+	Health *health.Checker
+	Images *images.Proxy
+
+	// Covers keeps the uploaded covers, served under /covers/, which may
+	// not exceed MaxCoverBytes.
+	Covers        storage.Store
+	MaxCoverBytes int64
+
+	// PublicURL is the absolute URL of the site, without trailing slash,
+	// for the canonical URLs of the pages. The requested host is used when
+	// it is empty, if it is one of AllowedHosts, or else the first of them.
+	PublicURL    string
+	AllowedHosts []string
+}
+
+// Options configures the middleware of the route groups.
+type Options struct {
+	// APILimit rate limits the public API, AdminLimit the admin API and the
+	// admin console, ImageLimit the covers of /img. Nil limiters do not
+	// limit.
+	APILimit   *ratelimit.Limiter
+	AdminLimit *ratelimit.Limiter
+	ImageLimit *ratelimit.Limiter
+
+	// TrustedProxies are allowed to report the client IP in
+	// X-Forwarded-For, for the logs.
+	TrustedProxies []*net.IPNet
+
+	// PublicCORS is the CORS policy of the read API and the pages, WriteCORS
+	// of mutating API requests and AdminCORS of the admin API and console.
+	// Nil policies reject cross origin requests.
+	PublicCORS *cors.Config
+	WriteCORS  *cors.Config
+	AdminCORS  *cors.Config
+
+	// Timeouts bounds the database queries of the endpoints.
+	Timeouts Timeouts
+
+	// Health runs the checks of /readyz, only pinging the database when nil.
+	Health *health.Checker
+
+	// Images renders the covers of /img, with a cache under cache/images
+	// when nil. Its sources under /covers/ are read from Covers.
+	Images *images.Proxy
+
+	// Covers keeps the uploaded covers, in the covers directory when nil.
+	// MaxCoverBytes bounds their size, 5MB when zero.
+	Covers        storage.Store
+	MaxCoverBytes int64
+
+	// PublicURL and AllowedHosts make the URLs of the site absolute, see
+	// Controller.
+	PublicURL    string
+	AllowedHosts []string
 }
 
 // Make .
-func Make(dao *model.DAO) *Controller {
-	ctr := &Controller{dao, gin.Default()}
-	ctr.Router.Use(cors.Default())
-	ctr.Router.LoadHTMLGlob("public/*")
-	ctr.Router.GET("/", ctr.PageLanding)
-	ctr.Router.GET("/filter", ctr.PageFilter)
-	ctr.Router.GET("/book/:id", ctr.PageBook)
-	ctr.Router.GET("/author/:id", ctr.PageAuthor)
-	ctr.Router.GET("/category/:id", ctr.PageCategory)
-	ctr.Router.GET("/tag/:id", ctr.PageTag)
+func Make(dao *model.DAO, authn *auth.Authenticator, opts Options) *Controller {
+	ctr := &Controller{
+		DAO:           dao,
+		Auth:          authn,
+		Router:        gin.New(),
+		Health:        opts.Health,
+		Images:        opts.Images,
+		Covers:        opts.Covers,
+		MaxCoverBytes: opts.MaxCoverBytes,
+		PublicURL:     strings.TrimSuffix(opts.PublicURL, "/"),
+		AllowedHosts:  opts.AllowedHosts,
+	}
+	if ctr.Health == nil {
+		ctr.Health = &health.Checker{Checks: []health.Check{{Name: "database", Run: dao.Ping}}}
+	}
+	if ctr.Images == nil {
+		ctr.Images = images.New(images.Options{
+			CacheDir:       "cache/images",
+			CacheMaxBytes:  256 << 20,
+			FetchTimeout:   10 * time.Second,
+			MaxSourceBytes: 10 << 20,
+		})
+	}
+	if ctr.Covers == nil {
+		ctr.Covers = storage.Local{Dir: "covers"}
+	}
+	if ctr.MaxCoverBytes == 0 {
+		ctr.MaxCoverBytes = 5 << 20
+	}
+	if ctr.Images.Open == nil {
+		ctr.Images.Open = ctr.openCover
+	}
+	clientIP := func(r *http.Request) string { return ratelimit.ClientIP(r, opts.TrustedProxies) }
+	ctr.Router.Use(logging.Middleware(clientIP), httputil.Recovery(), metrics.Middleware(), tracing.Middleware(), corsPolicies(opts))
+	ctr.Router.NoRoute(httputil.NoRoute)
+	ctr.Router.GET("/healthz", ctr.Healthz)
+	ctr.Router.GET("/readyz", ctr.Readyz)
+	// scrape with an admin key as the bearer token
+	ctr.Router.GET("/metrics", authn.Require(auth.ScopeAdmin), gin.WrapH(metrics.Handler()))
+	pages, err := web.Load(template.FuncMap{"thumbnail": thumbnail})
+	if err != nil {
+		panic(err)
+	}
+	ctr.Router.HTMLRender = pages
+	ctr.Router.Group("/static", cacheFor(time.Hour)).StaticFS("/", web.Static())
+	list, get := deadline(opts.Timeouts.List), deadline(opts.Timeouts.Get)
+	ctr.Router.GET("/", list, ctr.PageLanding)
+	ctr.Router.GET("/filter", list, ctr.PageFilter)
+	ctr.Router.GET("/book/:id", get, ctr.PageBook)
+	ctr.Router.GET("/author/:id", get, ctr.PageAuthor)
+	ctr.Router.GET("/category/:id", get, ctr.PageCategory)
+	ctr.Router.GET("/tag/:id", get, ctr.PageTag)
+	feeds := cacheFor(15 * time.Minute)
+	ctr.Router.GET("/feed/new.atom", list, feeds, ctr.Feed("", atomFeed))
+	ctr.Router.GET("/feed/new.rss", list, feeds, ctr.Feed("", rssFeed))
+	ctr.Router.GET("/author/:id/feed.atom", list, feeds, ctr.Feed("authors", atomFeed))
+	ctr.Router.GET("/author/:id/feed.rss", list, feeds, ctr.Feed("authors", rssFeed))
+	ctr.Router.GET("/category/:id/feed.atom", list, feeds, ctr.Feed("categories", atomFeed))
+	ctr.Router.GET("/category/:id/feed.rss", list, feeds, ctr.Feed("categories", rssFeed))
+	ctr.Router.GET("/tag/:id/feed.atom", list, feeds, ctr.Feed("tags", atomFeed))
+	ctr.Router.GET("/tag/:id/feed.rss", list, feeds, ctr.Feed("tags", rssFeed))
+	ctr.Router.GET("/robots.txt", cacheFor(time.Hour), ctr.Robots)
+	ctr.Router.GET("/sitemap.xml", list, cacheFor(time.Hour), ctr.Sitemap)
+	ctr.Router.GET("/sitemap-:file", list, cacheFor(time.Hour), ctr.SitemapChunk)
+	ctr.Router.GET("/covers/:file", ctr.Cover)
+	ctr.Router.GET("/img/book/:id", opts.ImageLimit.Handler(), get, ctr.BookImage)
+	console := ctr.Router.Group("/admin", opts.AdminLimit.Handler(), deadline(opts.Timeouts.Admin), ctr.session)
+	{
+		console.GET("/login", ctr.ConsoleLogin)
+		console.POST("/login", ctr.ConsoleLoginPost)
+		console.POST("/logout", ctr.ConsoleLogout)
+		editor := console.Group("", requireRole(auth.RoleEditor))
+		editor.GET("", ctr.ConsoleBooks)
+		editor.GET("/new", ctr.ConsoleNewBook)
+		editor.POST("/book", ctr.ConsoleCreateBook)
+		editor.GET("/book/:id", ctr.ConsoleBook)
+		editor.POST("/book/:id", ctr.ConsoleUpdateBook)
+		editor.POST("/book/:id/cover", ctr.ConsoleUploadCover)
+		editor.POST("/book/:id/item", ctr.ConsoleAddItem)
+		editor.POST("/book/:id/item/remove", ctr.ConsoleRemoveItem)
+		editor.GET("/items/:entity", ctr.ConsoleItems)
+		editor.POST("/items/:entity/:id", ctr.ConsoleRenameItem)
+		admin := console.Group("", requireRole(auth.RoleAdmin))
+		admin.POST("/book/:id/delete", ctr.ConsoleDeleteBook)
+		admin.POST("/items/:entity/:id/delete", ctr.ConsoleDeleteItem)
+	}
+	schema, err := ctr.Schema()
+	if err != nil {
+		panic(err)
+	}
 	api := ctr.Router.Group("/api")
+	// the version comes first for the errors of the limiter to take its shape
+	public := api.Group("", authn.Optional(), opts.APILimit.Handler())
+	{
+		public.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		public.GET("/graphql", deadline(opts.Timeouts.GraphQL), ctr.GraphQL(schema))
+		public.POST("/graphql", deadline(opts.Timeouts.GraphQL), ctr.GraphQL(schema))
+	}
+	ctr.routes(api.Group("", negotiate(), authn.Optional(), opts.APILimit.Handler()), opts.Timeouts)
+	ctr.routes(api.Group("/v1", apiVersion(v1), authn.Optional(), opts.APILimit.Handler()), opts.Timeouts)
+	ctr.routes(api.Group("/v2", apiVersion(v2), authn.Optional(), opts.APILimit.Handler()), opts.Timeouts)
+	admin := api.Group("/admin", apiVersion(v2), opts.AdminLimit.Handler(), deadline(opts.Timeouts.Admin), authn.Require(auth.ScopeAdmin))
+	{
+		admin.GET("/key", ctr.GetAllKey)
+		admin.GET("/broken-links", ctr.GetBrokenLinks)
+	}
+	write := api.Group("", negotiate(), opts.APILimit.Handler(), deadline(opts.Timeouts.Admin), authn.Require(auth.ScopeWrite))
 	{
-		api.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-		api.GET("/book", ctr.GetAllBook)
-		api.GET("/author", ctr.GetAllAuthor)
-		api.GET("/category", ctr.GetAllCategory)
-		api.GET("/tag", ctr.GetAllTag)
-		api.GET("/book/:id", ctr.GetBook)
-		api.GET("/author/:id", ctr.GetAuthor)
-		api.GET("/category/:id", ctr.GetCategory)
-		api.GET("/tag/:id", ctr.GetTag)
+		write.POST("/book/:id/cover", ctr.UploadBookCover)
 	}
 	return ctr
 }
 
+func (ctr *Controller) routes(api *gin.RouterGroup, timeouts Timeouts) {
+	list, get := deadline(timeouts.List), deadline(timeouts.Get)
+	api.GET("/book", list, ctr.GetAllBook)
+	api.GET("/author", list, ctr.GetAllAuthor)
+	api.GET("/category", list, ctr.GetAllCategory)
+	api.GET("/tag", list, ctr.GetAllTag)
+	api.GET("/book/:id", get, ctr.GetBook)
+	api.GET("/author/:id", get, ctr.GetAuthor)
+	api.GET("/category/:id", get, ctr.GetCategory)
+	api.GET("/tag/:id", get, ctr.GetTag)
+}
+
 // @title github.com/kautsarady/Adindopustaka API
 // @version 1.0
 // @description github.com/kautsarady/Adindopustaka API documentation
+// @description The API is versioned under /api/v1 and /api/v2. The unversioned paths serve the version the Accept header asks for, v1 by default. v2 wraps the responses in a data envelope, with the pagination in its metadata, dates the books and answers empty pages rather than 404.
 // @contact.name kautsarady
 // @contact.email kautsarady@gmail.com
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
 // GetAllBook godoc
 // @Summary Get All Book
 // @ID get-all-book
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param page query string false "page number (default=1)" Format(string)
-// @Param per_page query string false "per_page product count (default=20)" Format(string)
+// @Param per_page query string false "per_page product count (default=20, max=100)" Format(string)
+// @Param include query string false "comma separated relations to embed (authors,categories,tags)" Format(string)
+// @Param sort query string false "sort order (id,-id,title,-title)" Format(string)
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select" Format(string)
 // @Success 200 {array} model.Book
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/book [get]
+// @Router /api/v1/book [get]
+// @Router /api/v2/book [get]
 func (ctr *Controller) GetAllBook(ctx *gin.Context) {
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	books, err := ctr.DAO.Get("books", nil, limit, offset)
+	var order bookSort
+	if err := bindQuery(ctx, &order); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	fields, err := fieldset(ctx, "books")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if books == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	relations, err := includes(ctx, fields)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, books)
+	books, err := ctr.DAO.Get(ctx.Request.Context(), "books", fields, nil, order.Sort, limit, offset)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	if books == nil && notFound(ctx) {
+		return
+	}
+
+	result := model.ToBooks(books)
+	if err := ctr.DAO.LoadRelations(ctx.Request.Context(), result, relations...); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	respondPage(ctx, "book", limit, offset, result, fields)
 }
 
 // GetAllAuthor godoc
@@ -91,32 +294,42 @@ func (ctr *Controller) GetAllBook(ctx *gin.Context) {
 // @ID get-all-author
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param page query string false "page number (default=1)" Format(string)
-// @Param per_page query string false "per_page product count (default=20)" Format(string)
+// @Param per_page query string false "per_page product count (default=20, max=100)" Format(string)
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. name,books.title)" Format(string)
 // @Success 200 {array} model.Item
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/author [get]
+// @Router /api/v1/author [get]
+// @Router /api/v2/author [get]
 func (ctr *Controller) GetAllAuthor(ctx *gin.Context) {
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	authors, err := ctr.DAO.GetDistinctItems("authors", limit, offset)
+	fields, err := fieldset(ctx, "authors")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if authors == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	authors, err := ctr.DAO.GetDistinctItems(ctx.Request.Context(), "authors", limit, offset)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, authors)
+	if authors == nil && notFound(ctx) {
+		return
+	}
+
+	respondPage(ctx, "author", limit, offset, authors, fields)
 }
 
 // GetAllCategory godoc
@@ -124,32 +337,42 @@ func (ctr *Controller) GetAllAuthor(ctx *gin.Context) {
 // @ID get-all-category
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param page query string false "page number (default=1)" Format(string)
-// @Param per_page query string false "per_page product count (default=20)" Format(string)
+// @Param per_page query string false "per_page product count (default=20, max=100)" Format(string)
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. name,books.title)" Format(string)
 // @Success 200 {array} model.Item
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/category [get]
+// @Router /api/v1/category [get]
+// @Router /api/v2/category [get]
 func (ctr *Controller) GetAllCategory(ctx *gin.Context) {
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	categories, err := ctr.DAO.GetDistinctItems("categories", limit, offset)
+	fields, err := fieldset(ctx, "categories")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if categories == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	categories, err := ctr.DAO.GetDistinctItems(ctx.Request.Context(), "categories", limit, offset)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, categories)
+	if categories == nil && notFound(ctx) {
+		return
+	}
+
+	respondPage(ctx, "category", limit, offset, categories, fields)
 }
 
 // GetAllTag godoc
@@ -157,32 +380,42 @@ func (ctr *Controller) GetAllCategory(ctx *gin.Context) {
 // @ID get-all-tag
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param page query string false "page number (default=1)" Format(string)
-// @Param per_page query string false "per_page product count (default=20)" Format(string)
+// @Param per_page query string false "per_page product count (default=20, max=100)" Format(string)
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. name,books.title)" Format(string)
 // @Success 200 {array} model.Item
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/tag [get]
+// @Router /api/v1/tag [get]
+// @Router /api/v2/tag [get]
 func (ctr *Controller) GetAllTag(ctx *gin.Context) {
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	tags, err := ctr.DAO.GetDistinctItems("tags", limit, offset)
+	fields, err := fieldset(ctx, "tags")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if tags == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	tags, err := ctr.DAO.GetDistinctItems(ctx.Request.Context(), "tags", limit, offset)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, tags)
+	if tags == nil && notFound(ctx) {
+		return
+	}
+
+	respondPage(ctx, "tag", limit, offset, tags, fields)
 }
 
 // GetBook godoc
@@ -190,27 +423,36 @@ func (ctr *Controller) GetAllTag(ctx *gin.Context) {
 // @ID get-all-getBookByID
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param id path string true "book id to search"
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select" Format(string)
 // @Success 200 {object} model.Book
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/book/{id} [get]
+// @Router /api/v1/book/{id} [get]
+// @Router /api/v2/book/{id} [get]
 func (ctr *Controller) GetBook(ctx *gin.Context) {
-	id := ctx.Param("id")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
-	book, err := ctr.DAO.GetBookByID(id)
+	fields, err := fieldset(ctx, "books")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if book == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	book, err := ctr.DAO.GetBookByID(ctx.Request.Context(), strconv.Itoa(id), fields)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, book)
+	respond(ctx, book, fields)
 }
 
 // GetAuthor godoc
@@ -218,35 +460,44 @@ func (ctr *Controller) GetBook(ctx *gin.Context) {
 // @ID get-all-getAuthorByID
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param id path string true "author id to search"
 // @Param page query string false "page number of the item books (default=1)" Format(string)
-// @Param per_page query string false "per_page product count of the item books (default=20)" Format(string)
+// @Param per_page query string false "per_page product count of the item books (default=20, max=100)" Format(string)
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. name,books.title)" Format(string)
 // @Success 200 {object} model.Item
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/author/{id} [get]
+// @Router /api/v1/author/{id} [get]
+// @Router /api/v2/author/{id} [get]
 func (ctr *Controller) GetAuthor(ctx *gin.Context) {
-	id := ctx.Param("id")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	author, err := ctr.DAO.GetItemByID("authors", id, limit, offset)
+	fields, err := fieldset(ctx, "authors")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if author == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	author, err := ctr.DAO.GetItemByID(ctx.Request.Context(), "authors", strconv.Itoa(id), fields, limit, offset)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, author)
+	respondPage(ctx, "author/"+strconv.Itoa(id), limit, offset, author, fields)
 }
 
 // GetCategory godoc
@@ -254,35 +505,44 @@ func (ctr *Controller) GetAuthor(ctx *gin.Context) {
 // @ID get-all-getCategoryByID
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param id path string true "category id to search"
 // @Param page query string false "page number of the item books (default=1)" Format(string)
-// @Param per_page query string false "per_page product count of the item books (default=20)" Format(string)
+// @Param per_page query string false "per_page product count of the item books (default=20, max=100)" Format(string)
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. name,books.title)" Format(string)
 // @Success 200 {object} model.Item
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/category/{id} [get]
+// @Router /api/v1/category/{id} [get]
+// @Router /api/v2/category/{id} [get]
 func (ctr *Controller) GetCategory(ctx *gin.Context) {
-	id := ctx.Param("id")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	category, err := ctr.DAO.GetItemByID("categories", id, limit, offset)
+	fields, err := fieldset(ctx, "categories")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if category == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	category, err := ctr.DAO.GetItemByID(ctx.Request.Context(), "categories", strconv.Itoa(id), fields, limit, offset)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, category)
+	respondPage(ctx, "category/"+strconv.Itoa(id), limit, offset, category, fields)
 }
 
 // GetTag godoc
@@ -290,33 +550,42 @@ func (ctr *Controller) GetCategory(ctx *gin.Context) {
 // @ID get-all-getTagByID
 // @Accept json
 // @Produce json
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
 // @Param id path string true "tag id to search"
 // @Param page query string false "page number of the item books (default=1)" Format(string)
-// @Param per_page query string false "per_page product count of the item books (default=20)" Format(string)
+// @Param per_page query string false "per_page product count of the item books (default=20, max=100)" Format(string)
+// @Param fields query string false "comma separated fields to return, nested with dots (e.g. name,books.title)" Format(string)
 // @Success 200 {object} model.Item
-// @Failure 400 {object} httputil.HTTPError
-// @Failure 404 {object} httputil.HTTPError
+// @Failure 400 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
 // @Router /api/tag/{id} [get]
+// @Router /api/v1/tag/{id} [get]
+// @Router /api/v2/tag/{id} [get]
 func (ctr *Controller) GetTag(ctx *gin.Context) {
-	id := ctx.Param("id")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	tag, err := ctr.DAO.GetItemByID("tags", id, limit, offset)
+	fields, err := fieldset(ctx, "tags")
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if tag == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	tag, err := ctr.DAO.GetItemByID(ctx.Request.Context(), "tags", strconv.Itoa(id), fields, limit, offset)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, tag)
+	respondPage(ctx, "tag/"+strconv.Itoa(id), limit, offset, tag, fields)
 }
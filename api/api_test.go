@@ -0,0 +1,56 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/model"
+	_ "modernc.org/sqlite"
+)
+
+var testSecret = []byte("secret")
+
+// The catalog tables, in the shape of the MySQL ones.
+const (
+	booksTable      = "CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, image_url TEXT, gramed_url TEXT, description TEXT, updated_at DATETIME, created_at DATETIME)"
+	authorsTable    = "CREATE TABLE authors (id INTEGER, book_id INTEGER, name TEXT)"
+	categoriesTable = "CREATE TABLE categories (id INTEGER, book_id INTEGER, name TEXT)"
+	tagsTable       = "CREATE TABLE tags (id INTEGER, book_id INTEGER, name TEXT)"
+)
+
+// testDAO returns a DAO over an in-memory database set up by statements.
+func testDAO(t *testing.T, statements ...string) *model.DAO {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	for _, q := range statements {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(q, err)
+		}
+	}
+	return &model.DAO{DB: db}
+}
+
+// testController returns a controller over dao accepting the JWTs of bearer.
+func testController(dao *model.DAO, opts Options) *Controller {
+	return Make(dao, &auth.Authenticator{DAO: dao, HMACSecret: testSecret}, opts)
+}
+
+// bearer returns the Authorization of a JWT granting scope.
+func bearer(t *testing.T, scope string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "test",
+		"scope": scope,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
@@ -0,0 +1,386 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+const sessionKey = "session"
+
+// maxFormSize bounds the console forms, on top of the cover they may upload.
+const maxFormSize = 1 << 20
+
+// session loads the console session, starting an anonymous one when there
+// is none or it was ended server side, and checks the CSRF token of every
+// POST.
+func (ctr *Controller) session(ctx *gin.Context) {
+	sessions := ctr.Auth.Sessions
+	session := sessions.Load(ctx.Request)
+	if session != nil && session.User != "" {
+		ok, err := ctr.DAO.SessionExists(ctx.Request.Context(), auth.HashKey(session.ID))
+		if err != nil {
+			fail(ctx, err)
+			ctx.Abort()
+			return
+		}
+		if !ok {
+			session = nil
+		}
+	}
+	if session == nil {
+		var err error
+		if session, err = sessions.NewSession(); err != nil {
+			ctx.Error(err)
+			httputil.NewError(ctx, http.StatusInternalServerError, errors.New("cannot start session"))
+			ctx.Abort()
+			return
+		}
+		if err := sessions.Save(ctx.Writer, session); err != nil {
+			ctx.Error(err)
+			httputil.NewError(ctx, http.StatusInternalServerError, errors.New("cannot start session"))
+			ctx.Abort()
+			return
+		}
+	}
+
+	if ctx.Request.Method == http.MethodPost {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxFormSize+ctr.MaxCoverBytes)
+		if !session.ValidCSRF(ctx.PostForm("csrf_token")) {
+			httputil.NewError(ctx, http.StatusForbidden, errors.New("invalid CSRF token"))
+			ctx.Abort()
+			return
+		}
+	}
+
+	ctx.Set(sessionKey, session)
+}
+
+// requireRole redirects anonymous users to the login page and rejects users
+// without role.
+func requireRole(role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		session := ctx.MustGet(sessionKey).(*auth.Session)
+		if session.User == "" {
+			ctx.Redirect(http.StatusSeeOther, "/admin/login")
+			ctx.Abort()
+			return
+		}
+		if !session.Can(role) {
+			httputil.NewError(ctx, http.StatusForbidden, errors.New("requires role "+role))
+			ctx.Abort()
+		}
+	}
+}
+
+func sessionOf(ctx *gin.Context) *auth.Session {
+	return ctx.MustGet(sessionKey).(*auth.Session)
+}
+
+// ConsoleLogin .
+func (ctr *Controller) ConsoleLogin(ctx *gin.Context) {
+	ctx.HTML(http.StatusOK, "admin_login.html", gin.H{"Session": sessionOf(ctx)})
+}
+
+// ConsoleLoginPost .
+func (ctr *Controller) ConsoleLoginPost(ctx *gin.Context) {
+	user, err := ctr.DAO.GetUserByUsername(ctx.Request.Context(), ctx.PostForm("username"))
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	// unknown users are checked too, against no hash
+	var hash string
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if !auth.CheckPassword(hash, ctx.PostForm("password")) {
+		ctx.HTML(http.StatusUnauthorized, "admin_login.html", gin.H{
+			"Session": sessionOf(ctx),
+			"Error":   "invalid username or password",
+		})
+		return
+	}
+
+	sessions := ctr.Auth.Sessions
+	session, err := sessions.NewSession()
+	if err != nil {
+		ctx.Error(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("cannot start session"))
+		return
+	}
+	session.User, session.Role = user.Username, user.Role
+	if err := ctr.DAO.CreateSession(ctx.Request.Context(), auth.HashKey(session.ID), user.Username, time.Unix(session.Expires, 0)); err != nil {
+		fail(ctx, err)
+		return
+	}
+	if err := sessions.Save(ctx.Writer, session); err != nil {
+		ctx.Error(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("cannot start session"))
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, "/admin")
+}
+
+// ConsoleLogout ends the session server side, so that a copy of the cookie
+// is of no use either.
+func (ctr *Controller) ConsoleLogout(ctx *gin.Context) {
+	if session := sessionOf(ctx); session.User != "" {
+		if err := ctr.DAO.DeleteSession(ctx.Request.Context(), auth.HashKey(session.ID)); err != nil {
+			fail(ctx, err)
+			return
+		}
+	}
+	ctr.Auth.Sessions.Clear(ctx.Writer)
+	ctx.Redirect(http.StatusSeeOther, "/admin/login")
+}
+
+// ConsoleBooks .
+func (ctr *Controller) ConsoleBooks(ctx *gin.Context) {
+	limit, offset, err := paginate(ctx)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	books, err := ctr.DAO.Get(ctx.Request.Context(), "books", nil, nil, "", limit, offset)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "admin_books.html", gin.H{
+		"Session": sessionOf(ctx),
+		"Page":    wrapData("admin", limit, offset, model.ToBooks(books)),
+	})
+}
+
+// ConsoleNewBook .
+func (ctr *Controller) ConsoleNewBook(ctx *gin.Context) {
+	ctx.HTML(http.StatusOK, "admin_book.html", gin.H{
+		"Session":   sessionOf(ctx),
+		"Book":      model.Book{},
+		"Relations": model.Relations,
+	})
+}
+
+// ConsoleCreateBook .
+func (ctr *Controller) ConsoleCreateBook(ctx *gin.Context) {
+	book, err := bindBook(ctx)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	if err := ctr.DAO.CreateBook(ctx.Request.Context(), &book); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/book/%d", book.ID))
+}
+
+// ConsoleBook .
+func (ctr *Controller) ConsoleBook(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	book, err := ctr.DAO.GetBookByID(ctx.Request.Context(), strconv.Itoa(id), nil)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "admin_book.html", gin.H{
+		"Session":   sessionOf(ctx),
+		"Book":      book,
+		"Relations": model.Relations,
+	})
+}
+
+// ConsoleUpdateBook .
+func (ctr *Controller) ConsoleUpdateBook(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	book, err := bindBook(ctx)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	book.ID = id
+	if err := ctr.DAO.UpdateBook(ctx.Request.Context(), &book); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/book/%d", id))
+}
+
+// ConsoleDeleteBook .
+func (ctr *Controller) ConsoleDeleteBook(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := ctr.DAO.DeleteBook(ctx.Request.Context(), id); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, "/admin")
+}
+
+// ConsoleUploadCover stores the uploaded cover image and points the book
+// ImageURL at it.
+func (ctr *Controller) ConsoleUploadCover(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if _, ok := ctr.storeCover(ctx, id); !ok {
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/book/%d", id))
+}
+
+// ConsoleAddItem .
+func (ctr *Controller) ConsoleAddItem(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var form itemForm
+	if err := bindForm(ctx, &form); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	if err := ctr.DAO.AddItem(ctx.Request.Context(), form.Entity, id, form.Name); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/book/%d", id))
+}
+
+// ConsoleRemoveItem .
+func (ctr *Controller) ConsoleRemoveItem(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var form removeItemForm
+	if err := bindForm(ctx, &form); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	if err := ctr.DAO.RemoveItem(ctx.Request.Context(), form.Entity, form.ItemID, id); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, fmt.Sprintf("/admin/book/%d", id))
+}
+
+// ConsoleItems .
+func (ctr *Controller) ConsoleItems(ctx *gin.Context) {
+	entity := ctx.Param("entity")
+	if !model.IsRelation(entity) {
+		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+		return
+	}
+
+	limit, offset, err := paginate(ctx)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	items, err := ctr.DAO.GetDistinctItems(ctx.Request.Context(), entity, limit, offset)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "admin_items.html", gin.H{
+		"Session": sessionOf(ctx),
+		"Page":    wrapData("admin/items/"+entity, limit, offset, model.ToItems(items)),
+	})
+}
+
+// ConsoleRenameItem .
+func (ctr *Controller) ConsoleRenameItem(ctx *gin.Context) {
+	entity := ctx.Param("entity")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	var form renameForm
+	if err := bindForm(ctx, &form); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	if err := ctr.DAO.RenameItem(ctx.Request.Context(), entity, id, form.Name); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, "/admin/items/"+entity)
+}
+
+// ConsoleDeleteItem .
+func (ctr *Controller) ConsoleDeleteItem(ctx *gin.Context) {
+	entity := ctx.Param("entity")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if !model.IsRelation(entity) {
+		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+		return
+	}
+
+	if err := ctr.DAO.DeleteItem(ctx.Request.Context(), entity, id); err != nil {
+		fail(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusSeeOther, "/admin/items/"+entity)
+}
+
+func bindBook(ctx *gin.Context) (model.Book, error) {
+	var form bookForm
+	if err := bindForm(ctx, &form); err != nil {
+		return model.Book{}, err
+	}
+	return model.Book{
+		Title:       form.Title,
+		ImageURL:    form.ImageURL,
+		GramedURL:   form.GramedURL,
+		Description: form.Description,
+	}, nil
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+var csrfField = regexp.MustCompile(`name="csrf_token" value="(\w+)"`)
+
+// consoleFixture returns a controller with an editor ed, whose password is
+// password1.
+func consoleFixture(t *testing.T) *Controller {
+	ctr := testController(testDAO(t, booksTable,
+		"CREATE TABLE admin_users (id INTEGER PRIMARY KEY AUTOINCREMENT, username TEXT, password_hash TEXT, role TEXT)",
+		"CREATE TABLE admin_sessions (id TEXT PRIMARY KEY, username TEXT, expires_at DATETIME)",
+	), Options{})
+	hash, err := auth.HashPassword("password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctr.DAO.CreateUser(context.Background(), &model.User{Username: "ed", PasswordHash: hash, Role: auth.RoleEditor}); err != nil {
+		t.Fatal(err)
+	}
+	ctr.Auth.Sessions = &auth.SessionStore{Secret: []byte("session"), MaxAge: time.Hour, Secure: true}
+	return ctr
+}
+
+// consoleRequest sends a console request with cookie, returning the response
+// and the session cookie it set, if any.
+func consoleRequest(ctr *Controller, method, path string, form url.Values, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	ctr.Router.ServeHTTP(w, req)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == auth.SessionCookie {
+			return w, c
+		}
+	}
+	return w, nil
+}
+
+func TestConsoleLogoutEndsSession(t *testing.T) {
+	ctr := consoleFixture(t)
+
+	w, anonymous := consoleRequest(ctr, "GET", "/admin/login", nil, nil)
+	if anonymous == nil || !anonymous.Secure || !anonymous.HttpOnly {
+		t.Fatalf("session cookie = %+v, want Secure and HttpOnly", anonymous)
+	}
+	csrf := csrfField.FindStringSubmatch(w.Body.String())[1]
+
+	w, signedIn := consoleRequest(ctr, "POST", "/admin/login", url.Values{"csrf_token": {csrf}, "username": {"ed"}, "password": {"password1"}}, anonymous)
+	if w.Code != http.StatusSeeOther || signedIn == nil {
+		t.Fatalf("login = %d, cookie %v", w.Code, signedIn)
+	}
+	w, _ = consoleRequest(ctr, "GET", "/admin", nil, signedIn)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /admin after login = %d", w.Code)
+	}
+	csrf = csrfField.FindStringSubmatch(w.Body.String())[1]
+
+	if w, _ = consoleRequest(ctr, "POST", "/admin/logout", url.Values{"csrf_token": {csrf}}, signedIn); w.Code != http.StatusSeeOther {
+		t.Fatalf("logout = %d", w.Code)
+	}
+
+	// a copy of the cookie, still validly signed and unexpired, is no longer
+	// of use
+	w, _ = consoleRequest(ctr, "GET", "/admin", nil, signedIn)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin/login" {
+		t.Errorf("GET /admin after logout = %d %s, want a redirect to the login", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestConsoleRevokedUser(t *testing.T) {
+	ctr := consoleFixture(t)
+
+	w, anonymous := consoleRequest(ctr, "GET", "/admin/login", nil, nil)
+	csrf := csrfField.FindStringSubmatch(w.Body.String())[1]
+	_, signedIn := consoleRequest(ctr, "POST", "/admin/login", url.Values{"csrf_token": {csrf}, "username": {"ed"}, "password": {"password1"}}, anonymous)
+
+	n, err := ctr.DAO.DeleteUserSessions(context.Background(), "ed")
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteUserSessions = %d, %v", n, err)
+	}
+	w, _ = consoleRequest(ctr, "GET", "/admin", nil, signedIn)
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("GET /admin of a revoked user = %d, want %d", w.Code, http.StatusSeeOther)
+	}
+}
+
+func TestConsoleLoginRefused(t *testing.T) {
+	ctr := consoleFixture(t)
+
+	for _, form := range []url.Values{
+		{"username": {"ed"}, "password": {"password2"}},
+		{"username": {"nobody"}, "password": {"password1"}},
+	} {
+		w, anonymous := consoleRequest(ctr, "GET", "/admin/login", nil, nil)
+		form.Set("csrf_token", csrfField.FindStringSubmatch(w.Body.String())[1])
+		w, signedIn := consoleRequest(ctr, "POST", "/admin/login", form, anonymous)
+		if w.Code != http.StatusUnauthorized || signedIn != nil {
+			t.Errorf("login of %s = %d, cookie %v, want %d", form.Get("username"), w.Code, signedIn, http.StatusUnauthorized)
+		}
+	}
+}
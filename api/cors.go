@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// corsPolicies applies the CORS policy of the admin routes, the write API
+// or the public read API, depending on the request. It runs for every
+// request, unmatched ones included, so that preflights get answered.
+func corsPolicies(opts Options) gin.HandlerFunc {
+	public, write, admin := corsHandler(opts.PublicCORS), corsHandler(opts.WriteCORS), corsHandler(opts.AdminCORS)
+	return func(ctx *gin.Context) {
+		path, method := ctx.Request.URL.Path, ctx.Request.Method
+		if requested := ctx.GetHeader("Access-Control-Request-Method"); method == http.MethodOptions && requested != "" {
+			method = requested
+		}
+
+		switch {
+		case under(path, "/admin"), under(path, "/api/admin"):
+			admin(ctx)
+		case under(path, "/api") && path != "/api/graphql" && method != http.MethodGet && method != http.MethodHead:
+			write(ctx)
+		default:
+			public(ctx)
+		}
+	}
+}
+
+func corsHandler(config *cors.Config) gin.HandlerFunc {
+	if config == nil {
+		return func(*gin.Context) {}
+	}
+	return cors.New(*config)
+}
+
+func under(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
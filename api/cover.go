@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/logging"
+	"github.com/kautsarady/adindopustaka/model"
+	"github.com/kautsarady/adindopustaka/storage"
+)
+
+// coverPath prefixes the ImageURL of the uploaded covers.
+const coverPath = "/covers/"
+
+// multipartOverhead is the room left for the multipart framing around an
+// uploaded cover.
+const multipartOverhead = 64 << 10
+
+var coverTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// UploadBookCover godoc
+// @Summary Upload Book Cover
+// @Description Stores a JPEG, PNG, GIF or WebP cover and points the book image_url at it.
+// @ID upload-book-cover
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Accept header string false "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise"
+// @Param id path string true "book id"
+// @Param cover formData file true "cover image"
+// @Success 200 {object} model.Book
+// @Failure 400 {object} httputil.Problem
+// @Failure 401 {object} httputil.Problem
+// @Failure 403 {object} httputil.Problem
+// @Failure 404 {object} httputil.Problem
+// @Failure 406 {object} httputil.Problem
+// @Failure 413 {object} httputil.Problem
+// @Failure 415 {object} httputil.Problem
+// @Failure 503 {object} httputil.Problem
+// @Failure 504 {object} httputil.Problem
+// @Router /api/book/{id}/cover [post]
+func (ctr *Controller) UploadBookCover(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, ctr.MaxCoverBytes+multipartOverhead)
+	book, ok := ctr.storeCover(ctx, id)
+	if !ok {
+		return
+	}
+
+	respond(ctx, book, nil)
+}
+
+// storeCover stores the image uploaded in the cover field and points the
+// ImageURL of book id at it. It responds with the error when it fails.
+func (ctr *Controller) storeCover(ctx *gin.Context, id int) (*model.Book, bool) {
+	file, header, err := ctx.Request.FormFile("cover")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			coverTooLarge(ctx, ctr.MaxCoverBytes)
+			return nil, false
+		}
+		fail(ctx, model.Invalid("cover", "is required"))
+		return nil, false
+	}
+	defer file.Close()
+	if header.Size > ctr.MaxCoverBytes {
+		coverTooLarge(ctx, ctr.MaxCoverBytes)
+		return nil, false
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		fail(ctx, model.Invalid("cover", "cannot be read"))
+		return nil, false
+	}
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := coverTypes[contentType]
+	if !ok {
+		httputil.NewError(ctx, http.StatusUnsupportedMediaType, errors.New("cover must be a JPEG, PNG, GIF or WebP image"))
+		return nil, false
+	}
+
+	book, err := ctr.DAO.GetBookByID(ctx.Request.Context(), strconv.Itoa(id), nil)
+	if err != nil {
+		fail(ctx, err)
+		return nil, false
+	}
+
+	// the name changes with the content, so that caches never serve a
+	// replaced cover
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.MultiReader(bytes.NewReader(head[:n]), file)); err != nil {
+		fail(ctx, err)
+		return nil, false
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		fail(ctx, err)
+		return nil, false
+	}
+	name := fmt.Sprintf("%d-%s%s", id, hex.EncodeToString(hash.Sum(nil)[:4]), ext)
+
+	if err := ctr.Covers.Put(ctx.Request.Context(), name, file, header.Size, contentType); err != nil {
+		ctx.Error(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("cannot store cover image"))
+		return nil, false
+	}
+
+	old := book.ImageURL
+	book.ImageURL = coverPath + name
+	if err := ctr.DAO.SetBookImage(ctx.Request.Context(), id, book.ImageURL); err != nil {
+		if old != book.ImageURL {
+			ctr.deleteCover(ctx.Request.Context(), name)
+		}
+		fail(ctx, err)
+		return nil, false
+	}
+	if old != book.ImageURL && strings.HasPrefix(old, coverPath) {
+		ctr.deleteCover(ctx.Request.Context(), path.Base(old))
+	}
+	return book, true
+}
+
+// deleteCover removes a cover nothing points at anymore. Failing to only
+// leaves the blob behind, so it is logged rather than reported.
+func (ctr *Controller) deleteCover(ctx context.Context, name string) {
+	if err := ctr.Covers.Delete(context.WithoutCancel(ctx), name); err != nil {
+		logging.FromContext(ctx).Warn("cannot delete cover", "name", name, "error", err)
+	}
+}
+
+func coverTooLarge(ctx *gin.Context, max int64) {
+	httputil.NewError(ctx, http.StatusRequestEntityTooLarge, fmt.Errorf("cover must not exceed %d bytes", max))
+}
+
+// Cover serves the uploaded cover images.
+func (ctr *Controller) Cover(ctx *gin.Context) {
+	name := path.Base(ctx.Param("file"))
+	blob, err := ctr.Covers.Open(ctx.Request.Context(), name)
+	if errors.Is(err, storage.ErrNotExist) {
+		httputil.NewError(ctx, http.StatusNotFound, errors.New("cover not found"))
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("cannot read cover image"))
+		return
+	}
+	defer blob.Close()
+
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	if rs, ok := blob.(io.ReadSeeker); ok {
+		http.ServeContent(ctx.Writer, ctx.Request, name, time.Time{}, rs)
+		return
+	}
+	ctx.Status(http.StatusOK)
+	io.Copy(ctx.Writer, blob)
+}
+
+// openCover opens the uploaded covers, the sources under /covers/.
+func (ctr *Controller) openCover(ctx context.Context, src string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(src, coverPath) {
+		return nil, fmt.Errorf("unsupported cover source %q", src)
+	}
+	return ctr.Covers.Open(ctx, path.Base(src))
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/images"
+	"github.com/kautsarady/adindopustaka/model"
+	"github.com/kautsarady/adindopustaka/storage"
+)
+
+// coverFixture returns a controller over a single book, storing its covers
+// in dir.
+func coverFixture(t *testing.T, maxBytes int64) (*Controller, string) {
+	dao := testDAO(t, booksTable, authorsTable, categoriesTable, tagsTable,
+		"INSERT INTO books (id, title, image_url, gramed_url, description) VALUES (1, 'One', 'http://img/1', 'http://g/1', 'd1')")
+	dir := t.TempDir()
+	return testController(dao, Options{
+		Images:        images.New(images.Options{CacheDir: filepath.Join(dir, "cache"), CacheMaxBytes: 1 << 20}),
+		Covers:        storage.Local{Dir: filepath.Join(dir, "covers")},
+		MaxCoverBytes: maxBytes,
+	}), filepath.Join(dir, "covers")
+}
+
+func pngCover(t *testing.T, size int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func uploadCover(t *testing.T, ctr *Controller, id string, cover []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("cover", "cover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(cover)
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/api/book/"+id+"/cover", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", bearer(t, auth.ScopeWrite))
+	w := httptest.NewRecorder()
+	ctr.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadBookCover(t *testing.T) {
+	ctr, dir := coverFixture(t, 0)
+
+	var urls []string
+	for _, c := range []color.Color{color.White, color.Black} {
+		w := uploadCover(t, ctr, "1", pngCover(t, 8, c))
+		if w.Code != http.StatusOK {
+			t.Fatalf("upload = %d %s", w.Code, w.Body)
+		}
+		var book model.Book
+		if err := json.Unmarshal(w.Body.Bytes(), &book); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(book.ImageURL, coverPath+"1-") || !strings.HasSuffix(book.ImageURL, ".png") {
+			t.Errorf("image_url = %q", book.ImageURL)
+		}
+		if book.Title != "One" || book.GramedURL != "http://g/1" {
+			t.Errorf("upload changed the other fields: %+v", book)
+		}
+		urls = append(urls, book.ImageURL)
+	}
+	if urls[0] == urls[1] {
+		t.Fatalf("covers of different content share %q", urls[0])
+	}
+
+	var stored string
+	if err := ctr.DAO.DB.QueryRow("SELECT image_url FROM books WHERE id = 1").Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != urls[1] {
+		t.Errorf("stored image_url = %q, want %q", stored, urls[1])
+	}
+
+	// the replaced cover is deleted, the new one served
+	if _, err := os.Stat(filepath.Join(dir, strings.TrimPrefix(urls[0], coverPath))); !os.IsNotExist(err) {
+		t.Errorf("replaced cover still stored: %v", err)
+	}
+	w := httptest.NewRecorder()
+	ctr.Router.ServeHTTP(w, httptest.NewRequest("GET", urls[1], nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("GET %s = %d %s", urls[1], w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestUploadBookCoverErrors(t *testing.T) {
+	ctr, dir := coverFixture(t, 1024)
+
+	for _, tt := range []struct {
+		name   string
+		id     string
+		cover  []byte
+		status int
+	}{
+		{"too large", "1", append(pngCover(t, 1, color.White), make([]byte, 2048)...), http.StatusRequestEntityTooLarge},
+		{"not an image", "1", []byte("just some text"), http.StatusUnsupportedMediaType},
+		{"missing book", "2", pngCover(t, 8, color.White), http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := uploadCover(t, ctr, tt.id, tt.cover)
+			if w.Code != tt.status {
+				t.Errorf("upload = %d %s, want %d", w.Code, w.Body, tt.status)
+			}
+		})
+	}
+
+	var stored string
+	if err := ctr.DAO.DB.QueryRow("SELECT image_url FROM books WHERE id = 1").Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != "http://img/1" {
+		t.Errorf("image_url = %q after failed uploads", stored)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("%d covers stored after failed uploads", len(entries))
+	}
+}
+
+func TestUploadBookCoverRequiresWriteScope(t *testing.T) {
+	ctr, _ := coverFixture(t, 0)
+
+	req := httptest.NewRequest("POST", "/api/book/1/cover", strings.NewReader(""))
+	req.Header.Set("Authorization", bearer(t, auth.ScopeRead))
+	w := httptest.NewRecorder()
+	ctr.Router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("upload with %s = %d, want %d", auth.ScopeRead, w.Code, http.StatusForbidden)
+	}
+}
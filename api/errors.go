@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+// fail responds to an error returned by the DAO, mapping its domain errors
+// to a status. Anything unexpected is a 500 and its details are only logged.
+func fail(ctx *gin.Context, err error) {
+	var invalid *model.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		fields := make([]httputil.FieldError, len(invalid.Fields))
+		for i, f := range invalid.Fields {
+			fields[i] = httputil.FieldError{Field: f.Field, Message: f.Message}
+		}
+		httputil.NewInvalid(ctx, invalid.Error(), fields)
+	case errors.Is(err, model.ErrNotFound):
+		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	case errors.Is(err, model.ErrConflict):
+		httputil.NewError(ctx, http.StatusConflict, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		ctx.Error(err)
+		httputil.NewError(ctx, http.StatusGatewayTimeout, errors.New("database query timeout"))
+	default:
+		ctx.Error(err)
+		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
+	}
+}
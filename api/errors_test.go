@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kautsarady/adindopustaka/httputil"
+)
+
+func TestErrorShapes(t *testing.T) {
+	ctr := testController(testDAO(t, booksTable, authorsTable, categoriesTable, tagsTable), Options{})
+
+	for _, tt := range []struct {
+		url, accept string
+		legacy      bool
+	}{
+		{"/api/v1/book/42", "", true},
+		{"/api/v2/book/42", "", false},
+		{"/api/book/42", "", true},
+		{"/api/book/42", "application/vnd.adindopustaka.v1+json", true},
+		{"/api/book/42", "application/json; version=2", false},
+	} {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", tt.url, w.Code)
+			continue
+		}
+
+		if tt.legacy {
+			var body httputil.HTTPError
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != http.StatusNotFound || body.Message == "" {
+				t.Errorf("GET %s = %s, want {code, message}", tt.url, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+				t.Errorf("GET %s Content-Type = %q", tt.url, ct)
+			}
+			continue
+		}
+		var body httputil.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Status != http.StatusNotFound || body.Code != "not_found" {
+			t.Errorf("GET %s = %s, want a problem", tt.url, w.Body)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != httputil.ProblemType {
+			t.Errorf("GET %s Content-Type = %q", tt.url, ct)
+		}
+	}
+}
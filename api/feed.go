@@ -0,0 +1,246 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/images"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+// feedSize is how many of the books added last a feed lists.
+const feedSize = 20
+
+// Feed formats.
+const (
+	atomFeed = "atom"
+	rssFeed  = "rss"
+)
+
+// feedPages are the pages of the items having feeds, by entity.
+var feedPages = map[string]string{
+	"authors":    "/author/",
+	"categories": "/category/",
+	"tags":       "/tag/",
+}
+
+// feed is a list of books in either format.
+type feed struct {
+	Title string
+	// Page is the path of the page listing the books, Path the path of the
+	// feed without its extension.
+	Page, Path string
+	Books      []model.Book
+}
+
+// Feed returns the handler of the feed of the books added last in format,
+// atom or rss. When entity is not empty, the feed lists the books of the
+// item whose id is in the path.
+func (ctr *Controller) Feed(entity, format string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		f := feed{Title: "New books", Page: "/", Path: "/feed/new"}
+		var id int
+		if entity != "" {
+			var ok bool
+			if id, ok = idParam(ctx, "id"); !ok {
+				return
+			}
+			items, err := ctr.DAO.Get(ctx.Request.Context(), entity, nil, []string{fmt.Sprintf("id = %d", id)}, "", 1, 0)
+			if err != nil {
+				fail(ctx, err)
+				return
+			}
+			if len(items) == 0 {
+				fail(ctx, model.ErrNotFound)
+				return
+			}
+
+			name := model.ToItems(items)[0].Name
+			switch entity {
+			case "authors":
+				f.Title = "New books by " + name
+			case "categories":
+				f.Title = "New " + name + " books"
+			default:
+				f.Title = "New books tagged " + name
+			}
+			f.Page = feedPages[entity] + strconv.Itoa(id)
+			f.Path = f.Page + "/feed"
+		}
+
+		books, err := ctr.DAO.GetNewBooks(ctx.Request.Context(), entity, id, feedSize)
+		if err != nil {
+			fail(ctx, err)
+			return
+		}
+		ctr.hideBrokenImages(ctx, books)
+		f.Books = books
+
+		if format == rssFeed {
+			writeXML(ctx, "application/rss+xml; charset=utf-8", ctr.rss(ctx, f))
+			return
+		}
+		writeXML(ctx, "application/atom+xml; charset=utf-8", ctr.atom(ctx, f))
+	}
+}
+
+// feedContent describes a book in HTML, its cover above its description.
+func (ctr *Controller) feedContent(ctx *gin.Context, book model.Book) string {
+	var b strings.Builder
+	if book.ImageURL != "" && !book.ImageBroken {
+		fmt.Fprintf(&b, `<p><img src="%s" alt="%s"></p>`,
+			html.EscapeString(ctr.absURL(ctx, thumbnailAs(book.ID, book.ImageURL, 600, images.JPEG))), html.EscapeString(book.Title))
+	}
+	for _, paragraph := range strings.Split(book.Description, "\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(paragraph))
+		}
+	}
+	return b.String()
+}
+
+// updated is the last change of book, which the feeds only list when dated.
+func updated(book model.Book) time.Time {
+	if book.UpdatedAt != nil {
+		return *book.UpdatedAt
+	}
+	return *book.CreatedAt
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title     string       `xml:"title"`
+	ID        string       `xml:"id"`
+	Link      atomLink     `xml:"link"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Authors   []atomPerson `xml:"author"`
+	Summary   string       `xml:"summary,omitempty"`
+	Content   atomText     `xml:"content"`
+}
+
+type atomDoc struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atom renders f as an Atom feed, updated when its last book changed.
+func (ctr *Controller) atom(ctx *gin.Context, f feed) atomDoc {
+	self := ctr.absURL(ctx, f.Path+".atom")
+	doc := atomDoc{
+		Title: f.Title + " | " + siteName,
+		ID:    self,
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: self},
+			{Rel: "alternate", Type: "text/html", Href: ctr.absURL(ctx, f.Page)},
+		},
+		Author: atomPerson{Name: siteName, URI: ctr.absURL(ctx, "/")},
+	}
+
+	var last time.Time
+	for _, book := range f.Books {
+		url := ctr.absURL(ctx, fmt.Sprintf("/book/%d", book.ID))
+		entry := atomEntry{
+			Title:     book.Title,
+			ID:        url,
+			Link:      atomLink{Rel: "alternate", Type: "text/html", Href: url},
+			Published: book.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   updated(book).UTC().Format(time.RFC3339),
+			Summary:   summary(book.Description),
+			Content:   atomText{Type: "html", Text: ctr.feedContent(ctx, book)},
+		}
+		for _, author := range book.Authors {
+			entry.Authors = append(entry.Authors, atomPerson{
+				Name: author.Name,
+				URI:  ctr.absURL(ctx, fmt.Sprintf("/author/%d", author.ID)),
+			})
+		}
+		if t := updated(book); t.After(last) {
+			last = t
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	if last.IsZero() {
+		last = time.Now()
+	}
+	doc.Updated = last.UTC().Format(time.RFC3339)
+	return doc
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssDoc struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// rss renders f as an RSS 2.0 feed.
+func (ctr *Controller) rss(ctx *gin.Context, f feed) rssDoc {
+	doc := rssDoc{Version: "2.0"}
+	doc.Channel.Title = f.Title + " | " + siteName
+	doc.Channel.Link = ctr.absURL(ctx, f.Page)
+	doc.Channel.Description = f.Title + " on " + siteName + "."
+
+	var last time.Time
+	for _, book := range f.Books {
+		url := ctr.absURL(ctx, fmt.Sprintf("/book/%d", book.ID))
+		item := rssItem{
+			Title:       book.Title,
+			Link:        url,
+			GUID:        rssGUID{IsPermaLink: true, Value: url},
+			PubDate:     book.CreatedAt.UTC().Format(time.RFC1123Z),
+			Description: ctr.feedContent(ctx, book),
+		}
+		if t := updated(book); t.After(last) {
+			last = t
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	if !last.IsZero() {
+		doc.Channel.LastBuildDate = last.UTC().Format(time.RFC1123Z)
+	}
+	return doc
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFeedSkipsUndatedBooks(t *testing.T) {
+	// book 1 came with the data, book 2 was added
+	ctr := testController(testDAO(t, booksTable, authorsTable, categoriesTable, tagsTable,
+		"INSERT INTO books (id, title, image_url, gramed_url, description) VALUES (1, 'One', '', '', 'd1')",
+		"INSERT INTO books (id, title, image_url, gramed_url, description, created_at) VALUES (2, 'Two', '', '', 'd2', '2024-02-01 00:00:00')",
+	), Options{PublicURL: "https://books.example.com"})
+
+	for _, path := range []string{"/feed/new.atom", "/feed/new.rss"} {
+		w := httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s", path, w.Code, w.Body)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, "/book/2<") {
+			t.Errorf("GET %s does not list the added book:\n%s", path, body)
+		}
+		if strings.Contains(body, "/book/1<") {
+			t.Errorf("GET %s lists the undated book:\n%s", path, body)
+		}
+	}
+}
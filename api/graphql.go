@@ -0,0 +1,476 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/handler"
+	"github.com/kautsarady/adindopustaka/metrics"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+type connection struct {
+	Page    int         `json:"page"`
+	PerPage int         `json:"per_page"`
+	Next    int         `json:"next"`
+	Prev    int         `json:"prev"`
+	Nodes   interface{} `json:"nodes"`
+}
+
+// loader batches every key requested while a level of the query is resolved
+// into a single fetch, which runs once the first of its thunks is called.
+type loader struct {
+	name    string
+	mu      sync.Mutex
+	fetch   func(keys []int) (map[int]interface{}, error)
+	pending []int
+	results map[int]interface{}
+	err     error
+}
+
+func (l *loader) load(key int) func() (interface{}, error) {
+	l.mu.Lock()
+	_, hit := l.results[key]
+	if !hit {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+	metrics.CacheLookup(l.name, hit)
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 && l.err == nil {
+			keys := l.pending
+			l.pending = nil
+			results, err := l.fetch(keys)
+			if err != nil {
+				l.err = err
+			}
+			for k, v := range results {
+				l.results[k] = v
+			}
+		}
+		if l.err != nil {
+			return nil, l.err
+		}
+		return l.results[key], nil
+	}
+}
+
+type loadersKey struct{}
+
+// loaders holds the per request loaders, those of the relations of the books
+// keyed by relation and those of the books of the items by relation and page.
+type loaders struct {
+	ctr       *Controller
+	ctx       context.Context
+	relations map[string]*loader
+
+	mu    sync.Mutex
+	books map[string]*loader
+}
+
+func (ctr *Controller) newLoaders(ctx context.Context) *loaders {
+	ls := &loaders{ctr: ctr, ctx: ctx, relations: map[string]*loader{}, books: map[string]*loader{}}
+	for _, relation := range model.Relations {
+		relation := relation
+		ls.relations[relation] = &loader{
+			name: "graphql_" + relation,
+			fetch: func(bookIDs []int) (map[int]interface{}, error) {
+				books := make([]model.Book, len(bookIDs))
+				for i, id := range bookIDs {
+					books[i].ID = id
+				}
+				if err := ctr.DAO.LoadRelations(ctx, books, relation); err != nil {
+					return nil, err
+				}
+				results := make(map[int]interface{}, len(books))
+				for i := range books {
+					results[books[i].ID] = *model.RelationOf(&books[i], relation)
+				}
+				return results, nil
+			},
+			results: map[int]interface{}{},
+		}
+	}
+	return ls
+}
+
+// booksOf returns the loader of a page of the books of the items of relation.
+func (ls *loaders) booksOf(relation string, page, perPage int) *loader {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	key := fmt.Sprintf("%s/%d/%d", relation, page, perPage)
+	if l, ok := ls.books[key]; ok {
+		return l
+	}
+	l := &loader{
+		name: "graphql_" + relation + "_books",
+		fetch: func(itemIDs []int) (map[int]interface{}, error) {
+			itemBooks, err := ls.ctr.DAO.GetBooksByItems(ls.ctx, relation, itemIDs, perPage, (page-1)*perPage)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[int]interface{}, len(itemBooks))
+			for id, books := range itemBooks {
+				results[id] = books
+			}
+			return results, nil
+		},
+		results: map[int]interface{}{},
+	}
+	ls.books[key] = l
+	return l
+}
+
+func loadersOf(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+var pageArgs = graphql.FieldConfigArgument{
+	"page":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+	"per_page": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+}
+
+var idArgs = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+}
+
+func pageOf(args map[string]interface{}) (page, perPage int, err error) {
+	page, perPage = args["page"].(int), args["per_page"].(int)
+	if page < 1 || perPage < 1 {
+		return 0, 0, fmt.Errorf("page and per_page must be positive")
+	}
+	if perPage > maxPerPage {
+		return 0, 0, fmt.Errorf("per_page must be at most %d", maxPerPage)
+	}
+	return page, perPage, nil
+}
+
+func connect(page, perPage int, nodes interface{}) connection {
+	prev := 0
+	if page-1 > 0 {
+		prev = page - 1
+	}
+	return connection{page, perPage, page + 1, prev, nodes}
+}
+
+func connectionType(name string, node graphql.Output) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Connection",
+		Fields: graphql.Fields{
+			"page":     &graphql.Field{Type: graphql.Int},
+			"per_page": &graphql.Field{Type: graphql.Int},
+			"next":     &graphql.Field{Type: graphql.Int},
+			"prev":     &graphql.Field{Type: graphql.Int},
+			"nodes":    &graphql.Field{Type: graphql.NewList(node)},
+		},
+	})
+}
+
+// Schema builds the GraphQL schema of the catalog.
+func (ctr *Controller) Schema() (graphql.Schema, error) {
+	bookType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Book",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.Int},
+			"title":       &graphql.Field{Type: graphql.String},
+			"image_url":   &graphql.Field{Type: graphql.String},
+			"gramed_url":  &graphql.Field{Type: graphql.String},
+			"description": &graphql.Field{Type: graphql.String},
+		},
+	})
+	bookConnection := connectionType("Book", bookType)
+
+	query := graphql.Fields{
+		"books": &graphql.Field{
+			Type: bookConnection,
+			Args: pageArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				page, perPage, err := pageOf(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				books, err := ctr.DAO.Get(p.Context, "books", nil, nil, "", perPage, (page-1)*perPage)
+				if err != nil {
+					return nil, err
+				}
+				return connect(page, perPage, model.ToBooks(books)), nil
+			},
+		},
+		"book": &graphql.Field{
+			Type: bookType,
+			Args: idArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				books, err := ctr.DAO.Get(p.Context, "books", nil, []string{fmt.Sprintf("id = %d", p.Args["id"].(int))}, "", 1, 0)
+				if err != nil || len(books) == 0 {
+					return nil, err
+				}
+				return books[0], nil
+			},
+		},
+	}
+
+	for _, relation := range model.Relations {
+		relation := relation
+		name, single := typeNames(relation)
+
+		itemType := graphql.NewObject(graphql.ObjectConfig{
+			Name: name,
+			Fields: graphql.Fields{
+				"id":   &graphql.Field{Type: graphql.Int},
+				"name": &graphql.Field{Type: graphql.String},
+				"books": &graphql.Field{
+					Type: bookConnection,
+					Args: pageArgs,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						page, perPage, err := pageOf(p.Args)
+						if err != nil {
+							return nil, err
+						}
+						thunk := loadersOf(p.Context).booksOf(relation, page, perPage).load(p.Source.(model.Item).ID)
+						return func() (interface{}, error) {
+							result, err := thunk()
+							if err != nil {
+								return nil, err
+							}
+							books, _ := result.([]model.Book)
+							return connect(page, perPage, books), nil
+						}, nil
+					},
+				},
+			},
+		})
+
+		bookType.AddFieldConfig(relation, &graphql.Field{
+			Type: graphql.NewList(itemType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadersOf(p.Context).relations[relation].load(p.Source.(model.Book).ID), nil
+			},
+		})
+
+		query[relation] = &graphql.Field{
+			Type: connectionType(name, itemType),
+			Args: pageArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				page, perPage, err := pageOf(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				items, err := ctr.DAO.GetDistinctItems(p.Context, relation, perPage, (page-1)*perPage)
+				if err != nil {
+					return nil, err
+				}
+				return connect(page, perPage, model.ToItems(items)), nil
+			},
+		}
+
+		query[single] = &graphql.Field{
+			Type: itemType,
+			Args: idArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				items, err := ctr.DAO.Get(p.Context, relation, nil, []string{fmt.Sprintf("id = %d", p.Args["id"].(int))}, "", 1, 0)
+				if err != nil || len(items) == 0 {
+					return nil, err
+				}
+				return items[0], nil
+			},
+		}
+	}
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: query}),
+	})
+}
+
+// GraphQL serves the catalog schema, along with GraphiQL for browsers.
+func (ctr *Controller) GraphQL(schema graphql.Schema) gin.HandlerFunc {
+	h := handler.New(&handler.Config{
+		Schema:   &schema,
+		Pretty:   true,
+		GraphiQL: true,
+	})
+	return func(ctx *gin.Context) {
+		if err := limitQuery(&schema, ctx.Request); err != nil {
+			ctx.JSON(http.StatusBadRequest, graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+			return
+		}
+		c := ctx.Request.Context()
+		c = context.WithValue(c, loadersKey{}, ctr.newLoaders(c))
+		h.ContextHandler(c, ctx.Writer, ctx.Request)
+	}
+}
+
+// maxQueryDepth bounds the nesting of the fields of a query, and
+// maxQueryCost the fields it resolves: every page of a connection multiplies
+// the fields below it by its per_page, and every list of relations of a book
+// by relationWidth.
+const (
+	maxQueryDepth = 6
+	maxQueryCost  = 10000
+	relationWidth = 10
+)
+
+// limitQuery rejects a query nested deeper than maxQueryDepth or costing more
+// than maxQueryCost, leaving the body of r to be read again. Queries that do
+// not parse are left to the handler to report.
+func limitQuery(schema *graphql.Schema, r *http.Request) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	opts := handler.NewRequestOptions(r)
+	if r.Body != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if opts.Query == "" {
+		return nil
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: opts.Query})
+	if err != nil {
+		return nil
+	}
+	m := &measure{
+		schema:    schema,
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: opts.Variables,
+		spread:    map[string]bool{},
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		depth, cost := m.selections(schema.QueryType(), op.SelectionSet, 1)
+		if depth > maxQueryDepth {
+			return fmt.Errorf("query is nested deeper than %d fields", maxQueryDepth)
+		}
+		if cost > maxQueryCost {
+			return fmt.Errorf("query costs %d, more than %d", cost, maxQueryCost)
+		}
+	}
+	return nil
+}
+
+// measure walks a query along the schema.
+type measure struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	spread    map[string]bool
+}
+
+// selections returns how deep set nests fields of parent and how many fields
+// it resolves, each of them times times. Introspection fields, bounded by the
+// schema, and fields the schema does not have, which fail validation, are
+// not counted.
+func (m *measure) selections(parent *graphql.Object, set *ast.SelectionSet, times int) (depth, cost int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		d, c := 0, 0
+		switch selection := selection.(type) {
+		case *ast.Field:
+			def, ok := parent.Fields()[selection.Name.Value]
+			if !ok || strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			child, _ := graphql.GetNamed(def.Type).(*graphql.Object)
+			d, c = m.selections(child, selection.SelectionSet, times*m.width(parent, def, selection))
+			d, c = d+1, c+times
+		case *ast.InlineFragment:
+			d, c = m.selections(m.object(parent, selection.TypeCondition), selection.SelectionSet, times)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || m.spread[name] {
+				continue
+			}
+			m.spread[name] = true
+			d, c = m.selections(m.object(parent, fragment.TypeCondition), fragment.SelectionSet, times)
+			delete(m.spread, name)
+		}
+		if d > depth {
+			depth = d
+		}
+		cost += c
+	}
+	return depth, cost
+}
+
+// object is the type named by condition, parent when there is none.
+func (m *measure) object(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := m.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
+
+// width is how many times the fields below field of parent are resolved for
+// each time field is: the per_page of a connection, or relationWidth for a
+// list other than the nodes of a connection.
+func (m *measure) width(parent *graphql.Object, def *graphql.FieldDefinition, field *ast.Field) int {
+	for _, arg := range def.Args {
+		if arg.Name() != "per_page" {
+			continue
+		}
+		perPage, _ := arg.DefaultValue.(int)
+		for _, a := range field.Arguments {
+			if a.Name.Value != "per_page" {
+				continue
+			}
+			switch v := a.Value.(type) {
+			case *ast.IntValue:
+				perPage, _ = strconv.Atoi(v.Value)
+			case *ast.Variable:
+				if n, ok := m.variables[v.Name.Value].(float64); ok {
+					perPage = int(n)
+				}
+			}
+		}
+		// larger pages are refused when resolved
+		if perPage < 1 || perPage > maxPerPage {
+			perPage = maxPerPage
+		}
+		return perPage
+	}
+	if _, ok := def.Type.(*graphql.List); ok && !strings.HasSuffix(parent.Name(), "Connection") {
+		return relationWidth
+	}
+	return 1
+}
+
+func typeNames(relation string) (name, single string) {
+	switch relation {
+	case "authors":
+		return "Author", "author"
+	case "categories":
+		return "Category", "category"
+	default:
+		return "Tag", "tag"
+	}
+}
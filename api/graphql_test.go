@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// graphqlFixture returns a controller over three books of one author, Ann.
+func graphqlFixture(t *testing.T) *Controller {
+	return testController(testDAO(t, booksTable, authorsTable, categoriesTable, tagsTable,
+		"INSERT INTO books (id, title, image_url, gramed_url, description) VALUES (1, 'One', '', '', ''), (2, 'Two', '', '', ''), (3, 'Three', '', '', '')",
+		"INSERT INTO authors (id, book_id, name) VALUES (7, 1, 'ann'), (7, 2, 'ann'), (7, 3, 'ann')",
+	), Options{})
+}
+
+func graphqlQuery(ctr *Controller, query string, variables map[string]interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req := httptest.NewRequest("POST", "/api/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ctr.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	ctr := graphqlFixture(t)
+
+	for _, tt := range []struct {
+		name, query string
+		variables   map[string]interface{}
+		status      int
+	}{
+		{"at the depth limit", "{ books { nodes { authors { books { nodes { title } } } } } }", nil, http.StatusOK},
+		{"too deep", "{ books { nodes { authors { books { nodes { tags { name } } } } } } }", nil, http.StatusBadRequest},
+		{"too deep through fragments", "{ books { nodes { ...deep } } } fragment deep on Book { authors { books { nodes { ... on Book { tags { name } } } } } }", nil, http.StatusBadRequest},
+		{"too broad", "{ books(per_page: 100) { nodes { authors { books(per_page: 100) { nodes { title } } } } } }", nil, http.StatusBadRequest},
+		{"too broad through variables", "query($n: Int) { books(per_page: $n) { nodes { authors { books(per_page: $n) { nodes { title } } } } } }", map[string]interface{}{"n": 100}, http.StatusBadRequest},
+		{"narrow enough", "query($n: Int) { books(per_page: $n) { nodes { authors { books(per_page: $n) { nodes { title } } } } } }", map[string]interface{}{"n": 5}, http.StatusOK},
+		{"introspection", "{ __schema { types { fields { type { ofType { ofType { ofType { name } } } } } } } }", nil, http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := graphqlQuery(ctr, tt.query, tt.variables)
+			if w.Code != tt.status {
+				t.Errorf("query = %d %s, want %d", w.Code, w.Body, tt.status)
+			}
+			if tt.status == http.StatusOK && strings.Contains(w.Body.String(), `"errors"`) {
+				t.Errorf("query failed: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestGraphQLItemBooksPaged(t *testing.T) {
+	ctr := graphqlFixture(t)
+
+	w := graphqlQuery(ctr, `{ author(id: 7) { first: books(per_page: 2) { nodes { id } } last: books(page: 2, per_page: 2) { nodes { id } } } }`, nil)
+	var res struct {
+		Data struct {
+			Author struct {
+				First, Last struct {
+					Nodes []struct{ ID int }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err, w.Body)
+	}
+	ids := func(nodes []struct{ ID int }) (ids []int) {
+		for _, n := range nodes {
+			ids = append(ids, n.ID)
+		}
+		return ids
+	}
+	first, last := ids(res.Data.Author.First.Nodes), ids(res.Data.Author.Last.Nodes)
+	if len(first) != 2 || first[0] != 1 || first[1] != 2 || len(last) != 1 || last[0] != 3 {
+		t.Errorf("pages = %v and %v, want [1 2] and [3]: %s", first, last, w.Body)
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/health"
+)
+
+// Healthz reports that the process is alive, whatever its dependencies say.
+func (ctr *Controller) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readyz runs the readiness checks, answering 503 when any of them fails or
+// the service is draining.
+func (ctr *Controller) Readyz(ctx *gin.Context) {
+	report := ctr.Health.Run(ctx.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(status, report)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/images"
+	"github.com/kautsarady/adindopustaka/logging"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+// imageQuery is the query of the cover proxy.
+type imageQuery struct {
+	Width  int    `form:"w" binding:"omitempty,min=16,max=1200"`
+	Format string `form:"fmt" binding:"omitempty,oneof=jpeg png webp"`
+	// Version is the images.Version of the source, making the response
+	// cacheable forever.
+	Version string `form:"v"`
+}
+
+// BookImage serves the cover of a book resized to the w query and encoded as
+// fmt, falling back to a placeholder when the source cannot be rendered.
+func (ctr *Controller) BookImage(ctx *gin.Context) {
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	q := imageQuery{Format: images.JPEG}
+	if err := bindQuery(ctx, &q); err != nil {
+		fail(ctx, err)
+		return
+	}
+	q.Width = images.Snap(q.Width)
+
+	books, err := ctr.DAO.Get(ctx.Request.Context(), "books", model.Fields{"image_url": nil}, []string{fmt.Sprintf("id = %d", id)}, "", 1, 0)
+	if err != nil {
+		fail(ctx, err)
+		return
+	}
+	if len(books) == 0 {
+		fail(ctx, model.ErrNotFound)
+		return
+	}
+	src := books[0].(model.Book).ImageURL
+
+	data, err := ctr.Images.Render(ctx.Request.Context(), src, q.Width, q.Format)
+	if err != nil {
+		logging.FromContext(ctx.Request.Context()).Warn("cannot render cover", "book_id", id, "source", src, "error", err)
+		if data, err = images.Placeholder(q.Width, q.Format); err != nil {
+			fail(ctx, err)
+			return
+		}
+		// try the source again soon
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.Data(http.StatusOK, images.ContentType(q.Format), data)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s-%d-%s"`, images.Version(src), q.Width, q.Format)
+	if q.Version != "" && q.Version == images.Version(src) {
+		ctx.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		ctx.Header("Cache-Control", "public, max-age=86400")
+	}
+	ctx.Header("ETag", etag)
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, images.ContentType(q.Format), data)
+}
+
+// thumbnail is the URL of the cover of a book at width, for the templates.
+// Covers are photographs, which the lossless WebP encoder makes several times
+// heavier than JPEG.
+func thumbnail(id int, src string, width int) string {
+	return thumbnailAs(id, src, width, images.JPEG)
+}
+
+// thumbnailAs is thumbnail encoded as format.
+func thumbnailAs(id int, src string, width int, format string) string {
+	return fmt.Sprintf("/img/book/%d?w=%d&fmt=%s&v=%s", id, width, format, images.Version(src))
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kautsarady/adindopustaka/auth"
+)
+
+func TestMetricsRequireAdmin(t *testing.T) {
+	// the metrics read no table
+	ctr := testController(testDAO(t), Options{})
+
+	for _, tt := range []struct {
+		authorization string
+		status        int
+	}{
+		{"", http.StatusUnauthorized},
+		{bearer(t, auth.ScopeRead), http.StatusForbidden},
+		{bearer(t, auth.ScopeAdmin), http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		w := httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("GET /metrics with %q = %d, want %d", tt.authorization, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/kautsarady/adindopustaka/model"
+	"gopkg.in/go-playground/validator.v8"
+)
+
+// maxPerPage bounds per_page, keep in sync with the binding tag of pageQuery.
+const maxPerPage = 100
+
+// pageQuery is the pagination of list endpoints.
+type pageQuery struct {
+	Page    int `form:"page" binding:"min=1"`
+	PerPage int `form:"per_page" binding:"min=1,max=100"`
+}
+
+// bookSort is the sort order of the book list endpoint.
+type bookSort struct {
+	Sort string `form:"sort" binding:"omitempty,sort=books"`
+}
+
+// bookForm is the body of the console book forms.
+type bookForm struct {
+	Title       string `form:"title" binding:"required,max=255"`
+	ImageURL    string `form:"image_url" binding:"omitempty,image"`
+	GramedURL   string `form:"gramed_url" binding:"omitempty,weburl"`
+	Description string `form:"description"`
+}
+
+// itemForm is the body of the console form relating an item to a book.
+type itemForm struct {
+	Entity string `form:"entity" binding:"required,relation"`
+	Name   string `form:"name" binding:"required,max=255"`
+}
+
+// removeItemForm is the body of the console form unrelating an item.
+type removeItemForm struct {
+	Entity string `form:"entity" binding:"required,relation"`
+	ItemID int    `form:"item_id" binding:"required,min=1"`
+}
+
+// renameForm is the body of the console form renaming an item.
+type renameForm struct {
+	Name string `form:"name" binding:"required,max=255"`
+}
+
+func init() {
+	v := binding.Validator.Engine().(*validator.Validate)
+	v.RegisterValidation("sort", func(_ *validator.Validate, _, _, field reflect.Value, _ reflect.Type, _ reflect.Kind, entity string) bool {
+		for _, s := range model.Sorts[entity] {
+			if s == field.String() {
+				return true
+			}
+		}
+		return false
+	})
+	v.RegisterValidation("oneof", func(_ *validator.Validate, _, _, field reflect.Value, _ reflect.Type, _ reflect.Kind, values string) bool {
+		for _, value := range strings.Fields(values) {
+			if value == field.String() {
+				return true
+			}
+		}
+		return false
+	})
+	v.RegisterValidation("relation", func(_ *validator.Validate, _, _, field reflect.Value, _ reflect.Type, _ reflect.Kind, _ string) bool {
+		return model.IsRelation(field.String())
+	})
+	v.RegisterValidation("weburl", func(_ *validator.Validate, _, _, field reflect.Value, _ reflect.Type, _ reflect.Kind, _ string) bool {
+		return isWebURL(field.String())
+	})
+	// the image of a book is either elsewhere or an uploaded cover
+	v.RegisterValidation("image", func(_ *validator.Validate, _, _, field reflect.Value, _ reflect.Type, _ reflect.Kind, _ string) bool {
+		return isWebURL(field.String()) || isCover(field.String())
+	})
+}
+
+// isWebURL reports whether s is an absolute http or https URL, the links a
+// page can follow.
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isCover reports whether s is the path of an uploaded cover.
+func isCover(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme == "" && u.Host == "" && strings.HasPrefix(s, coverPath) && !strings.Contains(s, "..")
+}
+
+// bindQuery fills obj from the query string and checks it against its
+// binding tags.
+func bindQuery(ctx *gin.Context, obj interface{}) error {
+	if err := ctx.ShouldBindQuery(obj); err != nil {
+		return invalid(obj, ctx.Request.URL.Query(), err)
+	}
+	return nil
+}
+
+// bindForm fills obj from the request body and checks it against its
+// binding tags.
+func bindForm(ctx *gin.Context, obj interface{}) error {
+	if err := ctx.ShouldBindWith(obj, binding.Form); err != nil {
+		return invalid(obj, ctx.Request.PostForm, err)
+	}
+	return nil
+}
+
+// pathID parses the path parameter name as a positive integer ID.
+func pathID(ctx *gin.Context, name string) (int, error) {
+	id, err := strconv.Atoi(ctx.Param(name))
+	if err != nil || id < 1 {
+		return 0, model.Invalid(name, "must be a positive integer")
+	}
+	return id, nil
+}
+
+// idParam is pathID responding with 400 when the ID is invalid.
+func idParam(ctx *gin.Context, name string) (int, bool) {
+	id, err := pathID(ctx, name)
+	if err != nil {
+		fail(ctx, err)
+		return 0, false
+	}
+	return id, true
+}
+
+// invalid turns a binding error into a validation error naming the rejected
+// fields as they appear in the request.
+func invalid(obj interface{}, values url.Values, err error) error {
+	t := reflect.TypeOf(obj).Elem()
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		// the value of an integer field failed to parse
+		if fields := malformed(t, values); len(fields) > 0 {
+			return &model.ValidationError{Fields: fields}
+		}
+		return model.Invalid("request", "%v", err)
+	}
+
+	var fields []model.FieldError
+	for _, e := range errs {
+		fields = append(fields, model.FieldError{Field: formName(t, e.Name), Message: message(e)})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return &model.ValidationError{Fields: fields}
+}
+
+// malformed returns the integer fields of t whose value is not an integer.
+func malformed(t reflect.Type, values url.Values) (fields []model.FieldError) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("form")
+		if f.Type.Kind() != reflect.Int || name == "" {
+			continue
+		}
+		if _, ok := values[name]; !ok {
+			continue
+		}
+		if _, err := strconv.Atoi(values.Get(name)); err != nil {
+			fields = append(fields, model.FieldError{Field: name, Message: "must be an integer"})
+		}
+	}
+	return fields
+}
+
+// formName returns the form name of the field of t named name.
+func formName(t reflect.Type, name string) string {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Name == name && f.Tag.Get("form") != "" {
+			return f.Tag.Get("form")
+		}
+	}
+	return name
+}
+
+func message(e *validator.FieldError) string {
+	switch e.Tag {
+	case "required":
+		return "is required"
+	case "min":
+		if e.Kind == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", e.Param)
+		}
+		return "must be at least " + e.Param
+	case "max":
+		if e.Kind == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", e.Param)
+		}
+		return "must be at most " + e.Param
+	case "sort":
+		return "must be one of " + strings.Join(model.Sorts[e.Param], ", ")
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(e.Param), ", ")
+	case "relation":
+		return "must be one of " + strings.Join(model.Relations, ", ")
+	case "weburl":
+		return "must be an http or https URL"
+	case "image":
+		return "must be an http or https URL or an uploaded cover"
+	}
+	return "is invalid"
+}
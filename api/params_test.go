@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+func TestBookFormURLs(t *testing.T) {
+	for _, c := range []struct {
+		field, value string
+		ok           bool
+	}{
+		{"image_url", "https://example.com/a.jpg", true},
+		{"image_url", "http://example.com/a.jpg", true},
+		{"image_url", "/covers/1-abcd.png", true},
+		{"image_url", "/covers/../config.yml", false},
+		{"image_url", "/etc/passwd", false},
+		{"image_url", "javascript:alert(1)", false},
+		{"image_url", "file:///etc/passwd", false},
+		{"gramed_url", "https://www.gramedia.com/products/a", true},
+		{"gramed_url", "/covers/1-abcd.png", false},
+		{"gramed_url", "javascript:alert(1)", false},
+		{"gramed_url", "ftp://example.com/a", false},
+		{"gramed_url", "https://", false},
+	} {
+		form := url.Values{"title": {"A"}, c.field: {c.value}}
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		ctx.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		err := bindForm(ctx, &bookForm{})
+		if c.ok && err != nil {
+			t.Errorf("%s=%s: %v", c.field, c.value, err)
+		}
+		if !c.ok {
+			verr, _ := err.(*model.ValidationError)
+			if verr == nil || len(verr.Fields) != 1 || verr.Fields[0].Field != c.field {
+				t.Errorf("%s=%s: err = %v, want a validation error of %s", c.field, c.value, err, c.field)
+			}
+		}
+	}
+}
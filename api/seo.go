@@ -0,0 +1,171 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/images"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+// siteName ends the title of every page.
+const siteName = "Adindopustaka"
+
+// maxDescription is the length search engines show of a meta description.
+const maxDescription = 160
+
+// seo is the metadata of a page for search engines and link previews.
+type seo struct {
+	Title       string
+	Description string
+	// Canonical is the absolute URL of the page, without the query
+	// parameters that do not change its content.
+	Canonical string
+	// Type is the OpenGraph type, website when empty.
+	Type string
+	// Image is the absolute URL of the preview image.
+	Image string
+	// JSONLD is the schema.org description of the page, rendered as JSON.
+	JSONLD interface{}
+	// Feed is the absolute URL of the feeds of the page, without the .atom
+	// or .rss extension.
+	Feed string
+}
+
+// pageTitle numbers the pages of a listing after the first.
+func pageTitle(name string, page int) string {
+	if page > 1 {
+		name = fmt.Sprintf("%s, page %d", name, page)
+	}
+	return name + " | " + siteName
+}
+
+// absURL makes path absolute, see baseURL.
+func (ctr *Controller) absURL(ctx *gin.Context, path string) string {
+	return ctr.baseURL(ctx) + path
+}
+
+// baseURL is PublicURL, or else the URL of the host the request was sent to.
+// The Host header being up to the client, a host out of AllowedHosts is
+// replaced with the first of them. Without AllowedHosts the response is kept
+// out of shared caches, which would serve the URLs of a forged host to
+// everyone, so baseURL must be called before the response is written.
+func (ctr *Controller) baseURL(ctx *gin.Context) string {
+	if ctr.PublicURL != "" {
+		return ctr.PublicURL
+	}
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	host := ctx.Request.Host
+	if len(ctr.AllowedHosts) == 0 {
+		ctx.Header("Cache-Control", "private")
+		return scheme + "://" + host
+	}
+	for _, allowed := range ctr.AllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return scheme + "://" + host
+		}
+	}
+	return scheme + "://" + ctr.AllowedHosts[0]
+}
+
+// canonical is the canonical URL of the page of a listing at path, which
+// drops per_page and the page number of the first page.
+func (ctr *Controller) canonical(ctx *gin.Context, path string, limit, offset int) string {
+	if page := offset/limit + 1; page > 1 {
+		path = fmt.Sprintf("%s?page=%d", path, page)
+	}
+	return ctr.absURL(ctx, path)
+}
+
+// summary shortens text to a meta description, cutting between words.
+func summary(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxDescription {
+		return text
+	}
+	cut := string([]rune(text)[:maxDescription-1])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// bookSEO describes a book page, with its cover as preview image unless it is
+// broken.
+func (ctr *Controller) bookSEO(ctx *gin.Context, book *model.Book) seo {
+	url := ctr.absURL(ctx, fmt.Sprintf("/book/%d", book.ID))
+
+	var authors []string
+	for _, author := range book.Authors {
+		authors = append(authors, author.Name)
+	}
+	description := summary(book.Description)
+	if description == "" && len(authors) > 0 {
+		description = fmt.Sprintf("%s by %s", book.Title, strings.Join(authors, ", "))
+	}
+
+	var image string
+	if book.ImageURL != "" && !book.ImageBroken {
+		image = ctr.absURL(ctx, thumbnailAs(book.ID, book.ImageURL, 1200, images.JPEG))
+	}
+
+	return seo{
+		Title:       pageTitle(book.Title, 1),
+		Description: description,
+		Canonical:   url,
+		Type:        "book",
+		Image:       image,
+		JSONLD:      ctr.bookLD(ctx, book, url, image),
+	}
+}
+
+// bookLD is the schema.org Book of book.
+func (ctr *Controller) bookLD(ctx *gin.Context, book *model.Book, url, image string) map[string]interface{} {
+	ld := map[string]interface{}{
+		"@context": "https://schema.org",
+		"@type":    "Book",
+		"name":     book.Title,
+		"url":      url,
+	}
+	if book.Description != "" {
+		ld["description"] = book.Description
+	}
+	if image != "" {
+		ld["image"] = image
+	}
+	if book.GramedURL != "" {
+		ld["sameAs"] = book.GramedURL
+	}
+
+	var authors []map[string]string
+	for _, author := range book.Authors {
+		authors = append(authors, map[string]string{
+			"@type": "Person",
+			"name":  author.Name,
+			"url":   ctr.absURL(ctx, fmt.Sprintf("/author/%d", author.ID)),
+		})
+	}
+	if len(authors) > 0 {
+		ld["author"] = authors
+	}
+
+	var genres, keywords []string
+	for _, category := range book.Categories {
+		genres = append(genres, category.Name)
+	}
+	for _, tag := range book.Tags {
+		keywords = append(keywords, tag.Name)
+	}
+	if len(genres) > 0 {
+		ld["genre"] = genres
+	}
+	if len(keywords) > 0 {
+		ld["keywords"] = strings.Join(keywords, ", ")
+	}
+	return ld
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAbsURL(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		ctr          Controller
+		host         string
+		want, header string
+	}{
+		{"public url", Controller{PublicURL: "https://books.example.com"}, "evil.example", "https://books.example.com/book/1", ""},
+		{"allowed host", Controller{AllowedHosts: []string{"books.example.com", "www.books.example.com"}}, "WWW.books.example.com", "http://WWW.books.example.com/book/1", ""},
+		{"forged host", Controller{AllowedHosts: []string{"books.example.com"}}, "evil.example", "http://books.example.com/book/1", ""},
+		{"any host", Controller{}, "evil.example", "http://evil.example/book/1", "private"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest("GET", "/book/1", nil)
+			ctx.Request.Host = tt.host
+			ctx.Header("Cache-Control", "public, max-age=3600")
+			if tt.header == "" {
+				tt.header = "public, max-age=3600"
+			}
+
+			if got := tt.ctr.absURL(ctx, "/book/1"); got != tt.want {
+				t.Errorf("absURL = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.header {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.header)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/logging"
+)
+
+// sitemapSize is the range of ids listed by a child sitemap, well below the
+// 50,000 URLs a sitemap may hold.
+const sitemapSize = 10000
+
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapPages are the entities with pages, by the path of their pages.
+var sitemapPages = []struct{ entity, path string }{
+	{"books", "/book/"},
+	{"authors", "/author/"},
+	{"categories", "/category/"},
+	{"tags", "/tag/"},
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod,omitempty"`
+	// Priority ranks the URLs of a sitemap, 0.5 when empty.
+	Priority string `xml:"priority,omitempty"`
+}
+
+// undatedPriority ranks the pages of the books that came with the data, and
+// of the items having only such books, below the pages of added books.
+const undatedPriority = "0.3"
+
+// lastmod formats t as a W3C datetime, empty when unknown.
+func lastmod(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Sitemap serves the sitemap index, which lists a child sitemap per chunk of
+// sitemapSize ids of every entity having pages.
+func (ctr *Controller) Sitemap(ctx *gin.Context) {
+	var entries []sitemapEntry
+	for _, page := range sitemapPages {
+		chunks, err := ctr.DAO.GetChunks(ctx.Request.Context(), page.entity, sitemapSize)
+		if err != nil {
+			fail(ctx, err)
+			return
+		}
+		for _, chunk := range chunks {
+			entries = append(entries, sitemapEntry{
+				Loc:     ctr.absURL(ctx, fmt.Sprintf("/sitemap-%s-%d.xml", page.entity, chunk.Index+1)),
+				Lastmod: lastmod(chunk.Modified),
+			})
+		}
+	}
+
+	writeXML(ctx, "application/xml; charset=utf-8", struct {
+		XMLName  xml.Name       `xml:"sitemapindex"`
+		NS       string         `xml:"xmlns,attr"`
+		Sitemaps []sitemapEntry `xml:"sitemap"`
+	}{NS: sitemapNS, Sitemaps: entries})
+}
+
+// SitemapChunk serves a child sitemap, named after its entity and number
+// such as sitemap-books-1.xml, streaming its URLs from the database. The
+// sitemaps are served at the root, as a sitemap may only list the URLs under
+// its own directory.
+func (ctr *Controller) SitemapChunk(ctx *gin.Context) {
+	name := strings.TrimSuffix(ctx.Param("file"), ".xml")
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		sitemapNotFound(ctx)
+		return
+	}
+	number, err := strconv.Atoi(name[i+1:])
+	if err != nil || number < 1 {
+		sitemapNotFound(ctx)
+		return
+	}
+	entity, path := name[:i], ""
+	for _, page := range sitemapPages {
+		if page.entity == entity {
+			path = page.path
+		}
+	}
+	if path == "" {
+		sitemapNotFound(ctx)
+		return
+	}
+
+	// the response starts with the first URL, so that a failing query can
+	// still be answered with an error
+	base := ctr.baseURL(ctx)
+	var enc *xml.Encoder
+	urlset := xml.StartElement{
+		Name: xml.Name{Local: "urlset"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: sitemapNS}},
+	}
+	err = ctr.DAO.EachModified(ctx.Request.Context(), entity, number-1, sitemapSize, func(id int, modified *time.Time) error {
+		if enc == nil {
+			writeXMLHeader(ctx, "application/xml; charset=utf-8")
+			enc = xml.NewEncoder(ctx.Writer)
+			if err := enc.EncodeToken(urlset); err != nil {
+				return err
+			}
+		}
+		entry := sitemapEntry{Loc: base + path + strconv.Itoa(id), Lastmod: lastmod(modified)}
+		if modified == nil {
+			entry.Priority = undatedPriority
+		}
+		return enc.EncodeElement(entry, xml.StartElement{Name: xml.Name{Local: "url"}})
+	})
+	switch {
+	case enc == nil && err != nil:
+		fail(ctx, err)
+	case enc == nil:
+		sitemapNotFound(ctx)
+	case err != nil:
+		// too late for an error response, the crawler gets a truncated
+		// sitemap and tries again later
+		logging.FromContext(ctx.Request.Context()).Error("cannot write sitemap", "sitemap", name, "error", err)
+	default:
+		enc.EncodeToken(urlset.End())
+		enc.Flush()
+	}
+}
+
+func sitemapNotFound(ctx *gin.Context) {
+	httputil.NewError(ctx, http.StatusNotFound, errors.New("sitemap not found"))
+}
+
+// writeXMLHeader starts an XML response of contentType.
+func writeXMLHeader(ctx *gin.Context, contentType string) {
+	ctx.Header("Content-Type", contentType)
+	ctx.Status(http.StatusOK)
+	io.WriteString(ctx.Writer, xml.Header)
+}
+
+// writeXML responds with v encoded as XML.
+func writeXML(ctx *gin.Context, contentType string, v interface{}) {
+	writeXMLHeader(ctx, contentType)
+	xml.NewEncoder(ctx.Writer).Encode(v)
+}
+
+// Robots serves the robots.txt, keeping crawlers out of the API and the
+// admin console and pointing them at the sitemap.
+func (ctr *Controller) Robots(ctx *gin.Context) {
+	ctx.String(http.StatusOK, "User-agent: *\nDisallow: /admin\nDisallow: /api/\n\nSitemap: %s\n", ctr.absURL(ctx, "/sitemap.xml"))
+}
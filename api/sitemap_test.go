@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSitemapsAtRoot(t *testing.T) {
+	ctr := testController(testDAO(t, booksTable, authorsTable, categoriesTable, tagsTable,
+		"INSERT INTO books (id, title, image_url, gramed_url, description) VALUES (1, 'One', '', '', ''), (2, 'Two', '', '', '')",
+	), Options{PublicURL: "https://books.example.com"})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/sitemap.xml")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<loc>https://books.example.com/sitemap-books-1.xml</loc>") {
+		t.Fatalf("GET /sitemap.xml = %d %s, want the books sitemap at the root", w.Code, w.Body)
+	}
+
+	w = get("/sitemap-books-1.xml")
+	for _, loc := range []string{"https://books.example.com/book/1", "https://books.example.com/book/2"} {
+		if !strings.Contains(w.Body.String(), "<loc>"+loc+"</loc>") {
+			t.Errorf("GET /sitemap-books-1.xml = %d %s, want %s", w.Code, w.Body, loc)
+		}
+	}
+
+	for _, path := range []string{"/sitemap-books-2.xml", "/sitemap-books-0.xml", "/sitemap-users-1.xml", "/sitemap-books.xml", "/sitemap/books-1.xml"} {
+		if w := get(path); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
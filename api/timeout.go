@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeouts bounds the database queries of each kind of endpoint. Zero means
+// no deadline.
+type Timeouts struct {
+	Get     time.Duration
+	List    time.Duration
+	GraphQL time.Duration
+	Admin   time.Duration
+}
+
+// deadline cancels the queries of the request once d has passed, or as soon
+// as the client goes away.
+func deadline(d time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if d <= 0 {
+			ctx.Next()
+			return
+		}
+		c, cancel := context.WithTimeout(ctx.Request.Context(), d)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(c)
+		ctx.Next()
+	}
+}
@@ -1,9 +1,14 @@
 package api
 
 import (
-	"strconv"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/model"
 )
 
 type dataContext struct {
@@ -20,18 +25,97 @@ type metadata struct {
 }
 
 func paginate(ctx *gin.Context) (limit int, offset int, err error) {
-	pageNumS := ctx.DefaultQuery("page", "1")
-	perPageS := ctx.DefaultQuery("per_page", "20")
-	pageNum, err := strconv.Atoi(pageNumS)
-	if err != nil {
+	q := pageQuery{Page: 1, PerPage: 20}
+	if err := bindQuery(ctx, &q); err != nil {
 		return -1, -1, err
 	}
-	limit, err = strconv.Atoi(perPageS)
+	return q.PerPage, (q.Page - 1) * q.PerPage, nil
+}
+
+// includes returns the relations to embed in the books, those of the include
+// parameter, or those selected by fields when set, which drops the others.
+func includes(ctx *gin.Context, fields model.Fields) ([]string, error) {
+	var relations []string
+	if include := ctx.Query("include"); include != "" {
+		for _, relation := range strings.Split(include, ",") {
+			relation = strings.TrimSpace(relation)
+			if !model.IsRelation(relation) {
+				return nil, model.Invalid("include", "unknown relation %q", relation)
+			}
+			relations = append(relations, relation)
+		}
+	}
+	if fields != nil {
+		return fields.Relations(), nil
+	}
+	return relations, nil
+}
+
+func fieldset(ctx *gin.Context, entity string) (model.Fields, error) {
+	fields, err := model.ParseFields(ctx.Query("fields"))
+	if err == nil {
+		err = fields.Validate(entity)
+	}
+	if err == nil && ctx.GetString(httputil.VersionKey) != v2 {
+		err = withoutV2Fields(fields)
+	}
 	if err != nil {
-		return -1, -1, err
+		return nil, model.Invalid("fields", "%v", err)
+	}
+	return fields, nil
+}
+
+func withoutV2Fields(fields model.Fields) error {
+	for name, sub := range fields {
+		for _, field := range v2Fields {
+			if name == field {
+				return fmt.Errorf("field %q is only in v2", name)
+			}
+		}
+		if err := withoutV2Fields(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sparse drops every JSON field of data not selected by fields.
+func sparse(data interface{}, fields model.Fields) interface{} {
+	if fields == nil {
+		return data
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return data
+	}
+	return prune(v, fields)
+}
+
+func prune(v interface{}, fields model.Fields) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = prune(v[i], fields)
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			sub, ok := fields[key]
+			if !ok {
+				delete(v, key)
+				continue
+			}
+			if sub != nil {
+				v[key] = prune(value, sub)
+			}
+		}
 	}
-	offset = (pageNum - 1) * limit
-	return
+	return v
 }
 
 func wrapData(entity string, limit, offset int, data interface{}) dataContext {
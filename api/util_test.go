@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRelationFieldsInclude(t *testing.T) {
+	ctr := testController(testDAO(t, booksTable, authorsTable, categoriesTable, tagsTable,
+		"INSERT INTO books (id, title, image_url, gramed_url, description) VALUES (1, 'One', '', '', '')",
+		"INSERT INTO authors VALUES (7, 1, 'ann')",
+		"INSERT INTO tags VALUES (3, 1, 'old')",
+	), Options{})
+
+	for _, tt := range []struct {
+		path, want string
+	}{
+		{"/api/book?fields=title,authors.name", `[{"authors":[{"name":"Ann"}],"title":"One"}]`},
+		{"/api/book?fields=title,authors.name&include=tags", `[{"authors":[{"name":"Ann"}],"title":"One"}]`},
+		{"/api/book/1?fields=authors.name", `{"authors":[{"name":"Ann"}]}`},
+		{"/api/book?fields=title", `[{"title":"One"}]`},
+	} {
+		w := httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != tt.want {
+			t.Errorf("GET %s = %d %s, want %s", tt.path, w.Code, w.Body, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+const (
+	v1 = "v1"
+	v2 = "v2"
+)
+
+var vendorType = regexp.MustCompile(`^application/vnd\.adindopustaka\.(v\d+)\+json$`)
+
+// apiVersion pins every request of a group to version. The errors of v1 keep
+// the {code, message} shape of httputil.HTTPError, problem details being a v2
+// change.
+func apiVersion(version string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(httputil.VersionKey, version)
+		if version == v1 {
+			ctx.Set(httputil.LegacyKey, true)
+			deprecate(ctx)
+		}
+	}
+}
+
+// negotiate picks the version from the Accept header, falling back to v1.
+func negotiate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Vary", "Accept")
+		version := acceptedVersion(ctx.GetHeader("Accept"))
+		if version != v1 && version != v2 {
+			ctx.Set(httputil.VersionKey, v2)
+			httputil.NewError(ctx, http.StatusNotAcceptable, errors.New("unsupported API version "+version))
+			ctx.Abort()
+			return
+		}
+		apiVersion(version)(ctx)
+	}
+}
+
+// acceptedVersion returns the version of the first media range of accept
+// asking for one, either as application/vnd.adindopustaka.v2+json or
+// application/json; version=2, or v1 when none does.
+func acceptedVersion(accept string) string {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		if m := vendorType.FindStringSubmatch(mediaType); m != nil {
+			return m[1]
+		}
+		if version, ok := params["version"]; ok && mediaType == "application/json" {
+			return "v" + version
+		}
+	}
+	return v1
+}
+
+func deprecate(ctx *gin.Context) {
+	successor := ctx.Request.URL.Path
+	if strings.HasPrefix(successor, "/api/v1/") {
+		successor = "/api/v2/" + strings.TrimPrefix(successor, "/api/v1/")
+	} else {
+		successor = "/api/v2/" + strings.TrimPrefix(successor, "/api/")
+	}
+	ctx.Header("Deprecation", "true")
+	ctx.Header("Link", "<"+successor+`>; rel="successor-version"`)
+}
+
+// respond writes the fields of data as is for v1 and inside an envelope, with
+// the v2 fields, for v2.
+func respond(ctx *gin.Context, data interface{}, fields model.Fields) {
+	if ctx.GetString(httputil.VersionKey) == v2 {
+		ctx.JSON(http.StatusOK, gin.H{"data": sparse(present(data), fields)})
+		return
+	}
+	ctx.JSON(http.StatusOK, sparse(data, fields))
+}
+
+// respondPage is respond for paginated data, adding pagination metadata to
+// the v2 envelope.
+func respondPage(ctx *gin.Context, entity string, limit, offset int, data interface{}, fields model.Fields) {
+	if ctx.GetString(httputil.VersionKey) == v2 {
+		ctx.JSON(http.StatusOK, wrapData(entity, limit, offset, sparse(present(data), fields)))
+		return
+	}
+	ctx.JSON(http.StatusOK, sparse(data, fields))
+}
+
+// notFound responds 404 to the v1 requests for a page without data, reporting
+// whether it did. v2 responds with the empty page instead.
+func notFound(ctx *gin.Context) bool {
+	if ctx.GetString(httputil.VersionKey) == v2 {
+		return false
+	}
+	httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	return true
+}
+
+// v2Fields are the fields only v2 responses have.
+var v2Fields = []string{"created_at", "updated_at"}
+
+// bookV2 is a book as v2 returns it, dated.
+type bookV2 struct {
+	model.Book
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// itemV2 is an item as v2 returns it, with dated books.
+type itemV2 struct {
+	model.Item
+	Books []bookV2 `json:"books,omitempty"`
+}
+
+func presentBook(book model.Book) bookV2 {
+	return bookV2{book, book.CreatedAt, book.UpdatedAt}
+}
+
+func presentItem(item model.Item) itemV2 {
+	v := itemV2{Item: item}
+	for _, book := range item.Books {
+		v.Books = append(v.Books, presentBook(book))
+	}
+	return v
+}
+
+// present returns the books and items of data as v2 returns them.
+func present(data interface{}) interface{} {
+	switch data := data.(type) {
+	case *model.Book:
+		return presentBook(*data)
+	case []model.Book:
+		books := make([]bookV2, len(data))
+		for i, book := range data {
+			books[i] = presentBook(book)
+		}
+		return books
+	case *model.Item:
+		return presentItem(*data)
+	case []interface{}:
+		result := make([]interface{}, len(data))
+		for i, v := range data {
+			result[i] = present(v)
+		}
+		return result
+	case model.Book:
+		return presentBook(data)
+	case model.Item:
+		return presentItem(data)
+	}
+	return data
+}
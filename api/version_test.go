@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptedVersion(t *testing.T) {
+	for _, tt := range []struct {
+		accept, version string
+	}{
+		{"", v1},
+		{"application/json", v1},
+		{"application/json; version=2", v2},
+		{"application/json;version=20", "v20"},
+		{"application/json; charset=utf-8; version=1", v1},
+		{"application/vnd.adindopustaka.v2+json", v2},
+		{"application/vnd.adindopustaka.v2+jsonx", v1},
+		{"text/html, application/vnd.adindopustaka.v2+json;q=0.9", v2},
+		{"text/html; version=2", v1},
+	} {
+		if version := acceptedVersion(tt.accept); version != tt.version {
+			t.Errorf("acceptedVersion(%q) = %s, want %s", tt.accept, version, tt.version)
+		}
+	}
+}
+
+// versionFixture returns a controller over a book updated on 2024-03-01, by
+// author 1.
+func versionFixture(t *testing.T) *Controller {
+	return testController(testDAO(t, booksTable, authorsTable, categoriesTable, tagsTable,
+		"INSERT INTO books (id, title, image_url, gramed_url, description, updated_at) VALUES (1, 'One', '', '', '', '2024-03-01 00:00:00')",
+		"INSERT INTO authors VALUES (1, 1, 'ann')",
+	), Options{})
+}
+
+func TestV2Fields(t *testing.T) {
+	ctr := versionFixture(t)
+
+	for _, tt := range []struct {
+		path, accept string
+		status       int
+		dated        bool
+	}{
+		{"/api/book/1", "", http.StatusOK, false},
+		{"/api/v1/book/1", "", http.StatusOK, false},
+		{"/api/v2/book/1", "", http.StatusOK, true},
+		{"/api/book/1", "application/json; version=2", http.StatusOK, true},
+		{"/api/book/1", "application/json; version=20", http.StatusNotAcceptable, false},
+		{"/api/v2/book/1?fields=title,updated_at", "", http.StatusOK, true},
+		{"/api/v1/book/1?fields=title,updated_at", "", http.StatusBadRequest, false},
+	} {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("GET %s (%s) = %d %s, want %d", tt.path, tt.accept, w.Code, w.Body, tt.status)
+			continue
+		}
+		if dated := strings.Contains(w.Body.String(), `"updated_at":"2024-03-01T00:00:00Z"`); dated != tt.dated {
+			t.Errorf("GET %s (%s) dated = %v: %s", tt.path, tt.accept, dated, w.Body)
+		}
+	}
+}
+
+func TestEmptyPages(t *testing.T) {
+	ctr := versionFixture(t)
+
+	for _, path := range []string{"/book?page=2", "/author?page=2", "/category", "/tag"} {
+		w := httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1"+path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET /api/v1%s = %d, want %d", path, w.Code, http.StatusNotFound)
+		}
+
+		w = httptest.NewRecorder()
+		ctr.Router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v2"+path, nil))
+		var page struct {
+			Metadata metadata
+			Data     json.RawMessage
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || w.Code != http.StatusOK || string(page.Data) != "[]" {
+			t.Errorf("GET /api/v2%s = %d %s, want an empty page", path, w.Code, w.Body)
+		}
+	}
+}
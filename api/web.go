@@ -2,9 +2,13 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/logging"
 	"github.com/kautsarady/adindopustaka/model"
 
 	"github.com/gin-gonic/gin"
@@ -14,14 +18,13 @@ import (
 func (ctr *Controller) PageLanding(ctx *gin.Context) {
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	books, err := ctr.DAO.Get("books", nil, limit, offset)
+	books, err := ctr.DAO.Get(ctx.Request.Context(), "books", nil, nil, "", limit, offset)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
@@ -30,115 +33,157 @@ func (ctr *Controller) PageLanding(ctx *gin.Context) {
 		return
 	}
 
-	ctx.HTML(http.StatusOK, "index.html", wrapData("all", limit, offset, books))
+	result := model.ToBooks(books)
+	ctr.hideBrokenImages(ctx, result)
+
+	ctx.HTML(http.StatusOK, "index.html", gin.H{
+		"SEO": seo{
+			Title:       pageTitle("All books", offset/limit+1),
+			Description: "Browse the book catalog of " + siteName + ".",
+			Canonical:   ctr.canonical(ctx, "/", limit, offset),
+			Feed:        ctr.absURL(ctx, "/feed/new"),
+		},
+		"Page": wrapData("", limit, offset, result),
+	})
 }
 
 // PageBook .
 func (ctr *Controller) PageBook(ctx *gin.Context) {
-	id := ctx.Param("id")
-
-	book, err := ctr.DAO.GetBookByID(id)
-	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+	id, ok := idParam(ctx, "id")
+	if !ok {
 		return
 	}
 
-	if book == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
+	book, err := ctr.DAO.GetBookByID(ctx.Request.Context(), strconv.Itoa(id), nil)
+	if err != nil {
+		fail(ctx, err)
 		return
 	}
 
-	ctx.HTML(http.StatusOK, "detail.html", book)
+	books := []model.Book{*book}
+	ctr.hideBrokenImages(ctx, books)
+
+	ctx.HTML(http.StatusOK, "detail.html", gin.H{
+		"SEO":  ctr.bookSEO(ctx, &books[0]),
+		"Book": books[0],
+	})
 }
 
 // PageAuthor .
 func (ctr *Controller) PageAuthor(ctx *gin.Context) {
-	id := ctx.Param("id")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	author, err := ctr.DAO.GetItemByID("authors", id, limit, offset)
+	author, err := ctr.DAO.GetItemByID(ctx.Request.Context(), "authors", strconv.Itoa(id), nil, limit, offset)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if author == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
-		return
-	}
+	ctr.hideBrokenImages(ctx, author.Books)
 
-	ctx.HTML(http.StatusOK, "entity.html", wrapData("author/"+id, limit, offset, author))
+	ctx.HTML(http.StatusOK, "entity.html", gin.H{
+		"SEO": seo{
+			Title:       pageTitle("Books by "+author.Name, offset/limit+1),
+			Description: "Books written by " + author.Name + " on " + siteName + ".",
+			Canonical:   ctr.canonical(ctx, "/author/"+strconv.Itoa(id), limit, offset),
+			Feed:        ctr.absURL(ctx, "/author/"+strconv.Itoa(id)+"/feed"),
+		},
+		"Page": wrapData("author/"+strconv.Itoa(id), limit, offset, author),
+	})
 }
 
 // PageCategory .
 func (ctr *Controller) PageCategory(ctx *gin.Context) {
-	id := ctx.Param("id")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	categories, err := ctr.DAO.GetItemByID("categories", id, limit, offset)
+	categories, err := ctr.DAO.GetItemByID(ctx.Request.Context(), "categories", strconv.Itoa(id), nil, limit, offset)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if categories == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
-		return
-	}
+	ctr.hideBrokenImages(ctx, categories.Books)
 
-	ctx.HTML(http.StatusOK, "entity.html", wrapData("category/"+id, limit, offset, categories))
+	ctx.HTML(http.StatusOK, "entity.html", gin.H{
+		"SEO": seo{
+			Title:       pageTitle(categories.Name+" books", offset/limit+1),
+			Description: "Books in the " + categories.Name + " category on " + siteName + ".",
+			Canonical:   ctr.canonical(ctx, "/category/"+strconv.Itoa(id), limit, offset),
+			Feed:        ctr.absURL(ctx, "/category/"+strconv.Itoa(id)+"/feed"),
+		},
+		"Page": wrapData("category/"+strconv.Itoa(id), limit, offset, categories),
+	})
 }
 
 // PageTag .
 func (ctr *Controller) PageTag(ctx *gin.Context) {
-	id := ctx.Param("id")
+	id, ok := idParam(ctx, "id")
+	if !ok {
+		return
+	}
 
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	tags, err := ctr.DAO.GetItemByID("tags", id, limit, offset)
+	tags, err := ctr.DAO.GetItemByID(ctx.Request.Context(), "tags", strconv.Itoa(id), nil, limit, offset)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
-	if tags == nil {
-		httputil.NewError(ctx, http.StatusNotFound, errors.New("no corresponding data found"))
-		return
-	}
+	ctr.hideBrokenImages(ctx, tags.Books)
+
+	ctx.HTML(http.StatusOK, "entity.html", gin.H{
+		"SEO": seo{
+			Title:       pageTitle("Books tagged "+tags.Name, offset/limit+1),
+			Description: "Books tagged " + tags.Name + " on " + siteName + ".",
+			Canonical:   ctr.canonical(ctx, "/tag/"+strconv.Itoa(id), limit, offset),
+			Feed:        ctr.absURL(ctx, "/tag/"+strconv.Itoa(id)+"/feed"),
+		},
+		"Page": wrapData("tag/"+strconv.Itoa(id), limit, offset, tags),
+	})
+}
 
-	ctx.HTML(http.StatusOK, "entity.html", wrapData("tag/"+id, limit, offset, tags))
+// hideBrokenImages marks the books whose cover link was found broken, which
+// the templates leave out. Failing only shows the placeholders of /img.
+func (ctr *Controller) hideBrokenImages(ctx *gin.Context, books []model.Book) {
+	if err := ctr.DAO.MarkBrokenImages(ctx.Request.Context(), books); err != nil {
+		logging.FromContext(ctx.Request.Context()).Warn("cannot read broken images", "error", err)
+	}
 }
 
 // PageFilter .
 func (ctr *Controller) PageFilter(ctx *gin.Context) {
 	limit, offset, err := paginate(ctx)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusBadRequest, err)
+		fail(ctx, err)
 		return
 	}
 
-	authors, err := ctr.DAO.GetDistinctItems("authors", limit, offset)
+	authors, err := ctr.DAO.GetDistinctItems(ctx.Request.Context(), "authors", limit, offset)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
@@ -147,10 +192,9 @@ func (ctr *Controller) PageFilter(ctx *gin.Context) {
 		return
 	}
 
-	categories, err := ctr.DAO.GetDistinctItems("categories", limit, offset)
+	categories, err := ctr.DAO.GetDistinctItems(ctx.Request.Context(), "categories", limit, offset)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
@@ -159,10 +203,9 @@ func (ctr *Controller) PageFilter(ctx *gin.Context) {
 		return
 	}
 
-	tags, err := ctr.DAO.GetDistinctItems("tags", limit, offset)
+	tags, err := ctr.DAO.GetDistinctItems(ctx.Request.Context(), "tags", limit, offset)
 	if err != nil {
-		httputil.NewError(ctx, http.StatusInternalServerError, errors.New("database query failure"))
-		ctx.Error(err)
+		fail(ctx, err)
 		return
 	}
 
@@ -181,5 +224,20 @@ func (ctr *Controller) PageFilter(ctx *gin.Context) {
 		Tags:       model.ToItems(tags),
 	}
 
-	ctx.HTML(http.StatusOK, "filter.html", wrapData("ignore", limit, offset, data))
+	ctx.HTML(http.StatusOK, "filter.html", gin.H{
+		"SEO": seo{
+			Title:       pageTitle("Choose filter", offset/limit+1),
+			Description: "Browse the books of " + siteName + " by author, category or tag.",
+			Canonical:   ctr.canonical(ctx, "/filter", limit, offset),
+		},
+		"Page": wrapData("filter", limit, offset, data),
+	})
+}
+
+// cacheFor lets clients cache the responses for d.
+func cacheFor(d time.Duration) gin.HandlerFunc {
+	value := fmt.Sprintf("public, max-age=%d", int(d.Seconds()))
+	return func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", value)
+	}
 }
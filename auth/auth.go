@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+// Scopes understood by Require. Admin implies every other scope and
+// catalog:write implies catalog:read.
+const (
+	ScopeRead  = "catalog:read"
+	ScopeWrite = "catalog:write"
+	ScopeAdmin = "admin"
+)
+
+// PrincipalKey is the gin context key holding the authenticated *Principal.
+const PrincipalKey = "principal"
+
+// ErrUnavailable is returned when credentials cannot be verified, which is
+// not the fault of the caller.
+var ErrUnavailable = errors.New("cannot verify credentials")
+
+// Principal is the caller of an authenticated request.
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []string
+}
+
+// Has reports whether the principal was granted scope.
+func (p *Principal) Has(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin || (s == ScopeWrite && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator checks API keys and JWT bearer tokens, and the sessions of
+// the admin console. JWTs are accepted only when HMACSecret (HS256) or
+// RSAPublicKey (RS256) is set.
+type Authenticator struct {
+	DAO          *model.DAO
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+	Sessions     *SessionStore
+}
+
+// Require rejects requests whose caller is not granted every scope, and
+// answers 503 when the credentials cannot be verified.
+func (a *Authenticator) Require(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, err := a.authenticate(ctx)
+		if errors.Is(err, ErrUnavailable) {
+			ctx.Error(err)
+			httputil.NewError(ctx, http.StatusServiceUnavailable, ErrUnavailable)
+			ctx.Abort()
+			return
+		}
+		if err != nil {
+			ctx.Header("WWW-Authenticate", `Bearer realm="adindopustaka"`)
+			httputil.NewError(ctx, http.StatusUnauthorized, err)
+			ctx.Abort()
+			return
+		}
+
+		for _, scope := range scopes {
+			if !principal.Has(scope) {
+				httputil.NewError(ctx, http.StatusForbidden, errors.New("missing scope "+scope))
+				ctx.Abort()
+				return
+			}
+		}
+
+		ctx.Set(PrincipalKey, principal)
+	}
+}
+
+// Optional sets the principal of the requests with valid credentials, letting
+// every request through, for the rate limiters to tell the callers apart.
+func (a *Authenticator) Optional() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if principal, err := a.authenticate(ctx); err == nil {
+			ctx.Set(PrincipalKey, principal)
+		}
+	}
+}
+
+func (a *Authenticator) authenticate(ctx *gin.Context) (*Principal, error) {
+	token := ctx.GetHeader("X-API-Key")
+	if token == "" {
+		header := ctx.GetHeader("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			return nil, errors.New("missing credentials")
+		}
+		token = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+
+	if strings.HasPrefix(token, KeyPrefix) {
+		return a.authenticateKey(ctx.Request.Context(), token)
+	}
+	return a.authenticateJWT(token)
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, token string) (*Principal, error) {
+	key, err := a.DAO.GetKeyByHash(ctx, HashKey(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	if key == nil {
+		return nil, errors.New("invalid API key")
+	}
+	return &Principal{Subject: key.Name, Method: "api_key", Scopes: key.Scopes}, nil
+}
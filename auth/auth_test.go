@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/model"
+	_ "modernc.org/sqlite"
+)
+
+// keyFixture returns a database holding the key of writer, granted
+// catalog:write.
+func keyFixture(t *testing.T) (*sql.DB, *model.DAO, string) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	dao := &model.DAO{DB: db}
+	if _, err := db.Exec("CREATE TABLE api_keys (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, hash TEXT, scopes TEXT, created_at DATETIME, revoked_at DATETIME)"); err != nil {
+		t.Fatal(err)
+	}
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dao.CreateKey(context.Background(), &model.Key{Name: "writer", Hash: HashKey(key), Scopes: []string{ScopeWrite}}); err != nil {
+		t.Fatal(err)
+	}
+	return db, dao, key
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	db, dao, key := keyFixture(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", (&Authenticator{DAO: dao}).Require(ScopeRead), func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.MustGet(PrincipalKey).(*Principal).Subject)
+	})
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request(key); w.Code != http.StatusOK || w.Body.String() != "writer" {
+		t.Errorf("valid key = %d %s", w.Code, w.Body)
+	}
+	if w := request(KeyPrefix + "made-up"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// a failing database is no reason to tell the caller its key is wrong
+	db.Close()
+	if w := request(key); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("valid key without database = %d %s, want %d", w.Code, w.Body, http.StatusServiceUnavailable)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	_, dao, key := keyFixture(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", (&Authenticator{DAO: dao}).Optional(), func(ctx *gin.Context) {
+		subject := "anonymous"
+		if v, ok := ctx.Get(PrincipalKey); ok {
+			subject = v.(*Principal).Subject
+		}
+		ctx.String(http.StatusOK, subject)
+	})
+
+	for _, tt := range []struct {
+		key, subject string
+	}{
+		{key, "writer"},
+		{KeyPrefix + "made-up", "anonymous"},
+		{"", "anonymous"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != tt.subject {
+			t.Errorf("key %q = %d %s, want %s", tt.key, w.Code, w.Body, tt.subject)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		hash, password string
+		want           bool
+	}{
+		{hash, "password1", true},
+		{hash, "password2", false},
+		{"", "", false},
+		{"", "password1", false},
+	} {
+		if got := CheckPassword(c.hash, c.password); got != c.want {
+			t.Errorf("CheckPassword(%q, %q) = %v, want %v", c.hash, c.password, got, c.want)
+		}
+	}
+}
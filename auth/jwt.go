@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// claims are the JWT claims, with scopes as a space separated "scope" claim.
+type claims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+func (a *Authenticator) authenticateJWT(token string) (*Principal, error) {
+	var methods []string
+	if a.HMACSecret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if a.RSAPublicKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if methods == nil {
+		return nil, errors.New("bearer tokens are not accepted")
+	}
+
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		if t.Method == jwt.SigningMethodRS256 {
+			return a.RSAPublicKey, nil
+		}
+		return a.HMACSecret, nil
+	}, jwt.WithValidMethods(methods), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, errors.New("invalid bearer token")
+	}
+
+	return &Principal{Subject: c.Subject, Method: "jwt", Scopes: strings.Fields(c.Scope)}, nil
+}
+
+// LoadRSAPublicKey reads a PEM encoded RSA public key from path.
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseRSAPublicKeyFromPEM(pem)
+}
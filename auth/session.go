@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Roles of admin console users. Admin implies editor.
+const (
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// SessionCookie is the name of the admin console session cookie.
+const SessionCookie = "adindopustaka_session"
+
+// Session is the signed content of the session cookie. A session without
+// User is anonymous and only carries the CSRF token of the login form.
+type Session struct {
+	// ID identifies the session server side, where signing out or revoking
+	// the user ends it before it expires.
+	ID      string `json:"i,omitempty"`
+	User    string `json:"u,omitempty"`
+	Role    string `json:"r,omitempty"`
+	CSRF    string `json:"c"`
+	Expires int64  `json:"e"`
+}
+
+// Can reports whether the session user has role.
+func (s *Session) Can(role string) bool {
+	return s.User != "" && (s.Role == role || s.Role == RoleAdmin)
+}
+
+// ValidCSRF compares token with the session CSRF token in constant time.
+func (s *Session) ValidCSRF(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.CSRF)) == 1
+}
+
+// SessionStore keeps sessions in HMAC signed cookies, sent over HTTPS only
+// when Secure.
+type SessionStore struct {
+	Secret []byte
+	MaxAge time.Duration
+	Secure bool
+}
+
+// NewSession returns an anonymous session with a fresh ID and CSRF token.
+func (st *SessionStore) NewSession() (*Session, error) {
+	id, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+	csrf, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{ID: id, CSRF: csrf, Expires: time.Now().Add(st.MaxAge).Unix()}, nil
+}
+
+func randomHex() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Load returns the session of r, or nil when it is missing, tampered with or
+// expired.
+func (st *SessionStore) Load(r *http.Request) *Session {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return nil
+	}
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(st.sign(parts[0]))) {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil
+	}
+	var s Session
+	if err := json.Unmarshal(payload, &s); err != nil || time.Now().Unix() > s.Expires {
+		return nil
+	}
+	return &s
+}
+
+// Save writes s as the session cookie.
+func (st *SessionStore) Save(w http.ResponseWriter, s *Session) error {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	value := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    value + "." + st.sign(value),
+		Path:     "/admin",
+		Expires:  time.Unix(s.Expires, 0),
+		HttpOnly: true,
+		Secure:   st.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Clear removes the session cookie.
+func (st *SessionStore) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Path: "/admin", MaxAge: -1, HttpOnly: true, Secure: st.Secure})
+}
+
+func (st *SessionStore) sign(value string) string {
+	mac := hmac.New(sha256.New, st.Secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HashPassword .
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// dummyHash is a bcrypt hash of no password in use, which CheckPassword
+// compares against in place of an empty hash.
+const dummyHash = "$2a$10$esRyDCE95Y/f4YlqzillbOkC5TE/zSNh/lt4M3zKl/Tmb9me1pPB."
+
+// CheckPassword reports whether password matches hash. An empty hash, of a
+// user that does not exist, matches no password but takes as long to check,
+// so that the time of a login does not tell whether a username exists.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
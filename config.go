@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/kautsarady/adindopustaka/config"
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = `usage: adindopustaka [flags] config print
+
+prints the effective configuration as YAML, with secrets redacted`
+
+// printConfig shows the configuration resulting from every source, then
+// reports whether it is valid.
+func printConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
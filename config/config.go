@@ -0,0 +1,475 @@
+// Package config loads the service configuration from defaults, a YAML or
+// TOML file, environment variables and command line flags, in increasing
+// order of precedence.
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config .
+type Config struct {
+	Server    Server    `yaml:"server" toml:"server"`
+	DB        DB        `yaml:"db" toml:"db"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+	RateLimit RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+	CORS      CORS      `yaml:"cors" toml:"cors"`
+
+	QueryTimeout QueryTimeout `yaml:"query_timeout" toml:"query_timeout"`
+	Tracing      Tracing      `yaml:"tracing" toml:"tracing"`
+	Log          Log          `yaml:"log" toml:"log"`
+	Images       Images       `yaml:"images" toml:"images"`
+	Storage      Storage      `yaml:"storage" toml:"storage"`
+	LinkCheck    LinkCheck    `yaml:"link_check" toml:"link_check"`
+}
+
+// Server .
+type Server struct {
+	Port     int    `yaml:"port" toml:"port"`
+	GRPCPort int    `yaml:"grpc_port" toml:"grpc_port"`
+	CoverDir string `yaml:"cover_dir" toml:"cover_dir"`
+
+	// PublicURL is the absolute URL the site is served at, for the canonical
+	// URLs of the pages. The requested host is used when it is empty, if it
+	// is one of AllowedHosts, or else the first of them.
+	PublicURL    string   `yaml:"public_url" toml:"public_url"`
+	AllowedHosts []string `yaml:"allowed_hosts" toml:"allowed_hosts"`
+
+	// ReadinessTimeout bounds each check of /readyz.
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" toml:"readiness_timeout"`
+
+	// On shutdown /readyz fails for DrainDelay before the servers stop
+	// accepting requests, then in-flight requests get DrainTimeout to finish.
+	DrainDelay   time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	DrainTimeout time.Duration `yaml:"drain_timeout" toml:"drain_timeout"`
+
+	// TLSCertFile and TLSKeyFile enable HTTPS, over HTTP/2 unless HTTP2 is
+	// false.
+	TLSCertFile string `yaml:"tls_cert_file" toml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file" toml:"tls_key_file"`
+	HTTP2       bool   `yaml:"http2" toml:"http2"`
+
+	// TrustedProxies lists the CIDRs allowed to set X-Forwarded-For.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// DB .
+type DB struct {
+	User         string `yaml:"user" toml:"user"`
+	Password     string `yaml:"password" toml:"password"`
+	PasswordFile string `yaml:"password_file" toml:"password_file"`
+	Host         string `yaml:"host" toml:"host"`
+	Port         int    `yaml:"port" toml:"port"`
+	Name         string `yaml:"name" toml:"name"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+
+	// ConnectTimeout bounds the wait for the database at startup.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+
+	// ReadRetries is how many times a read failing with a transient error is
+	// retried.
+	ReadRetries int `yaml:"read_retries" toml:"read_retries"`
+}
+
+// DSN returns the MySQL data source name of the database. Updates report the
+// rows they match rather than the rows they change, an update leaving a row as
+// it was still having found it.
+func (db DB) DSN() string {
+	dsn := mysql.NewConfig()
+	dsn.User = db.User
+	dsn.Passwd = db.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(db.Host, strconv.Itoa(db.Port))
+	dsn.DBName = db.Name
+	dsn.ParseTime = true
+	dsn.ClientFoundRows = true
+	return dsn.FormatDSN()
+}
+
+// Auth .
+type Auth struct {
+	SessionSecret     string `yaml:"session_secret" toml:"session_secret"`
+	SessionSecretFile string `yaml:"session_secret_file" toml:"session_secret_file"`
+	// SessionMaxAge is how long a console session lasts unless its user
+	// signs out or is revoked. SessionSecure restricts the session cookie to
+	// HTTPS, which only a site served over plain HTTP may turn off.
+	SessionMaxAge time.Duration `yaml:"session_max_age" toml:"session_max_age"`
+	SessionSecure bool          `yaml:"session_secure" toml:"session_secure"`
+
+	JWTSecret        string `yaml:"jwt_secret" toml:"jwt_secret"`
+	JWTSecretFile    string `yaml:"jwt_secret_file" toml:"jwt_secret_file"`
+	JWTPublicKeyFile string `yaml:"jwt_public_key_file" toml:"jwt_public_key_file"`
+}
+
+// RateLimit .
+type RateLimit struct {
+	// Store is memory, or mysql to share limits between replicas.
+	Store string `yaml:"store" toml:"store"`
+	API   Limit  `yaml:"api" toml:"api"`
+	Admin Limit  `yaml:"admin" toml:"admin"`
+	// Images limits the covers of /img, which a page loads many of at once.
+	Images Limit `yaml:"images" toml:"images"`
+}
+
+// Limit is a token bucket refilled with Rate tokens per second. A zero rate
+// disables limiting.
+type Limit struct {
+	Rate  float64 `yaml:"rate" toml:"rate"`
+	Burst int     `yaml:"burst" toml:"burst"`
+}
+
+// CORS holds the policies of the public read API, of mutating API requests
+// and of the admin routes.
+type CORS struct {
+	Public Policy `yaml:"public" toml:"public"`
+	Write  Policy `yaml:"write" toml:"write"`
+	Admin  Policy `yaml:"admin" toml:"admin"`
+}
+
+// Policy is a CORS policy. Origins may be *, or contain wildcards like
+// https://*.example.com. A policy without origins rejects cross origin
+// requests.
+type Policy struct {
+	Origins     []string      `yaml:"origins" toml:"origins"`
+	Methods     []string      `yaml:"methods" toml:"methods"`
+	Headers     []string      `yaml:"headers" toml:"headers"`
+	Credentials bool          `yaml:"credentials" toml:"credentials"`
+	MaxAge      time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// QueryTimeout bounds the database queries of each kind of endpoint. Zero
+// means no deadline.
+type QueryTimeout struct {
+	Get     time.Duration `yaml:"get" toml:"get"`
+	List    time.Duration `yaml:"list" toml:"list"`
+	GraphQL time.Duration `yaml:"graphql" toml:"graphql"`
+	Admin   time.Duration `yaml:"admin" toml:"admin"`
+}
+
+// Tracing exports OpenTelemetry spans to an OTLP/HTTP collector.
+type Tracing struct {
+	// Endpoint is the collector URL, e.g. http://localhost:4318. Tracing is
+	// off when empty.
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// Images configures the resizing cover proxy of /img.
+type Images struct {
+	CacheDir string `yaml:"cache_dir" toml:"cache_dir"`
+	// CacheMaxMB bounds the disk cache, least recently used images go first.
+	CacheMaxMB   int           `yaml:"cache_max_mb" toml:"cache_max_mb"`
+	FetchTimeout time.Duration `yaml:"fetch_timeout" toml:"fetch_timeout"`
+	// MaxSourceMB bounds the size of the source images.
+	MaxSourceMB int `yaml:"max_source_mb" toml:"max_source_mb"`
+}
+
+// Storage configures where uploaded covers are kept.
+type Storage struct {
+	// Backend is local, keeping the covers in server.cover_dir, or s3.
+	Backend string `yaml:"backend" toml:"backend"`
+	S3      S3     `yaml:"s3" toml:"s3"`
+	// MaxUploadMB bounds the size of an uploaded cover.
+	MaxUploadMB int `yaml:"max_upload_mb" toml:"max_upload_mb"`
+}
+
+// S3 is a bucket of an S3 compatible service.
+type S3 struct {
+	// Endpoint is a host and port, without scheme.
+	Endpoint      string `yaml:"endpoint" toml:"endpoint"`
+	Region        string `yaml:"region" toml:"region"`
+	Bucket        string `yaml:"bucket" toml:"bucket"`
+	AccessKey     string `yaml:"access_key" toml:"access_key"`
+	SecretKey     string `yaml:"secret_key" toml:"secret_key"`
+	SecretKeyFile string `yaml:"secret_key_file" toml:"secret_key_file"`
+	UseSSL        bool   `yaml:"use_ssl" toml:"use_ssl"`
+}
+
+// LinkCheck configures the dead link checker, run by the check-links command
+// and in the background of the server.
+type LinkCheck struct {
+	// Interval is the time between background runs, 0 disables them. The
+	// replicas sharing a database take turns rather than each checking.
+	Interval    time.Duration `yaml:"interval" toml:"interval"`
+	Concurrency int           `yaml:"concurrency" toml:"concurrency"`
+	// Timeout bounds the check of one link.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// Log .
+type Log struct {
+	// Level is one of debug, info, warn or error.
+	Level string `yaml:"level" toml:"level"`
+	// Format is json or text.
+	Format string `yaml:"format" toml:"format"`
+}
+
+// Default returns the configuration used for everything left unset.
+func Default() *Config {
+	headers := []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key"}
+	return &Config{
+		Server: Server{
+			Port:             8080,
+			CoverDir:         "covers",
+			ReadinessTimeout: time.Second,
+			DrainDelay:       5 * time.Second,
+			DrainTimeout:     30 * time.Second,
+			HTTP2:            true,
+		},
+		DB: DB{
+			Port:            3306,
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnectTimeout:  30 * time.Second,
+			ReadRetries:     2,
+		},
+		RateLimit: RateLimit{
+			Store:  "memory",
+			API:    Limit{Rate: 10, Burst: 20},
+			Admin:  Limit{Rate: 1, Burst: 10},
+			Images: Limit{Rate: 20, Burst: 60},
+		},
+		QueryTimeout: QueryTimeout{
+			Get:     2 * time.Second,
+			List:    5 * time.Second,
+			GraphQL: 10 * time.Second,
+			Admin:   10 * time.Second,
+		},
+		Auth:      Auth{SessionMaxAge: 12 * time.Hour, SessionSecure: true},
+		Tracing:   Tracing{ServiceName: "adindopustaka", SampleRatio: 1},
+		Log:       Log{Level: "info", Format: "json"},
+		Images:    Images{CacheDir: "cache/images", CacheMaxMB: 256, FetchTimeout: 10 * time.Second, MaxSourceMB: 10},
+		Storage:   Storage{Backend: "local", S3: S3{UseSSL: true}, MaxUploadMB: 5},
+		LinkCheck: LinkCheck{Interval: 24 * time.Hour, Concurrency: 8, Timeout: 10 * time.Second},
+		CORS: CORS{
+			Public: Policy{
+				Origins: []string{"*"},
+				Methods: []string{"GET", "HEAD", "POST", "OPTIONS"},
+				Headers: headers,
+				MaxAge:  12 * time.Hour,
+			},
+			Write: Policy{
+				Methods: []string{"POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+				Headers: headers,
+				MaxAge:  time.Hour,
+			},
+			Admin: Policy{
+				Methods: []string{"GET", "POST", "OPTIONS"},
+				Headers: headers,
+				MaxAge:  time.Hour,
+			},
+		},
+	}
+}
+
+// Error lists every problem found by Validate.
+type Error struct{ Problems []string }
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Validate reports every invalid or missing setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+	problem := func(key, format string, args ...interface{}) {
+		hint := ""
+		if b, ok := bindingOf(key); ok {
+			hint = fmt.Sprintf(" (set %s, %s or -%s)", key, b.env, b.flag())
+		}
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...)+hint)
+	}
+	port := func(key string, port int, optional bool) {
+		if (port != 0 || !optional) && (port < 1 || port > 65535) {
+			problem(key, "%d is not a valid port", port)
+		}
+	}
+
+	port("server.port", c.Server.Port, false)
+	port("server.grpc_port", c.Server.GRPCPort, true)
+	if c.Server.GRPCPort != 0 && c.Server.GRPCPort == c.Server.Port {
+		problem("server.grpc_port", "must differ from server.port")
+	}
+	for key, value := range map[string]time.Duration{
+		"server.readiness_timeout": c.Server.ReadinessTimeout,
+		"server.drain_delay":       c.Server.DrainDelay,
+		"server.drain_timeout":     c.Server.DrainTimeout,
+	} {
+		if value < 0 {
+			problem(key, "must not be negative")
+		}
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		problem("server.tls_key_file", "server.tls_cert_file and server.tls_key_file go together")
+	}
+	if u := c.Server.PublicURL; u != "" && !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		problem("server.public_url", "%q must start with http:// or https://", u)
+	}
+	for _, host := range c.Server.AllowedHosts {
+		if host == "" || strings.ContainsAny(host, "/ ") {
+			problem("server.allowed_hosts", "%q is not a host", host)
+		}
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problem("server.trusted_proxies", "%q is neither an IP nor a CIDR", proxy)
+			}
+		}
+	}
+
+	for key, value := range map[string]string{"db.user": c.DB.User, "db.host": c.DB.Host, "db.name": c.DB.Name} {
+		if value == "" {
+			problem(key, "is required")
+		}
+	}
+	port("db.port", c.DB.Port, false)
+	for key, value := range map[string]int{"db.max_open_conns": c.DB.MaxOpenConns, "db.max_idle_conns": c.DB.MaxIdleConns, "db.read_retries": c.DB.ReadRetries} {
+		if value < 0 {
+			problem(key, "must not be negative")
+		}
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		problem("db.max_idle_conns", "must not exceed db.max_open_conns")
+	}
+	for key, value := range map[string]time.Duration{"db.conn_max_lifetime": c.DB.ConnMaxLifetime, "db.connect_timeout": c.DB.ConnectTimeout} {
+		if value < 0 {
+			problem(key, "must not be negative")
+		}
+	}
+
+	if c.Auth.SessionMaxAge <= 0 {
+		problem("auth.session_max_age", "must be positive")
+	}
+
+	switch c.RateLimit.Store {
+	case "memory", "mysql":
+	default:
+		problem("rate_limit.store", "unknown store %q, want memory or mysql", c.RateLimit.Store)
+	}
+	for name, limit := range map[string]Limit{"api": c.RateLimit.API, "admin": c.RateLimit.Admin, "images": c.RateLimit.Images} {
+		if limit.Rate < 0 {
+			problem("rate_limit."+name+".rate", "must not be negative")
+		}
+		if limit.Rate > 0 && limit.Burst < 1 {
+			problem("rate_limit."+name+".burst", "must be positive")
+		}
+	}
+
+	for name, policy := range map[string]Policy{"public": c.CORS.Public, "write": c.CORS.Write, "admin": c.CORS.Admin} {
+		prefix := "cors." + name + "."
+		for _, origin := range policy.Origins {
+			if origin == "*" {
+				if policy.Credentials {
+					problem(prefix+"credentials", "cannot be allowed for every origin")
+				}
+			} else if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+				problem(prefix+"origins", "%q must be * or start with http:// or https://", origin)
+			}
+		}
+		if len(policy.Origins) > 0 && len(policy.Methods) == 0 {
+			problem(prefix+"methods", "is required when origins are allowed")
+		}
+		if policy.MaxAge < 0 {
+			problem(prefix+"max_age", "must not be negative")
+		}
+	}
+
+	for key, value := range map[string]time.Duration{
+		"query_timeout.get":     c.QueryTimeout.Get,
+		"query_timeout.list":    c.QueryTimeout.List,
+		"query_timeout.graphql": c.QueryTimeout.GraphQL,
+		"query_timeout.admin":   c.QueryTimeout.Admin,
+	} {
+		if value < 0 {
+			problem(key, "must not be negative")
+		}
+	}
+
+	if c.Tracing.Endpoint != "" && !strings.HasPrefix(c.Tracing.Endpoint, "http://") && !strings.HasPrefix(c.Tracing.Endpoint, "https://") {
+		problem("tracing.endpoint", "%q must start with http:// or https://", c.Tracing.Endpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problem("tracing.sample_ratio", "must be between 0 and 1")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		problem("log.level", "%q is not one of debug, info, warn or error", c.Log.Level)
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		problem("log.format", "%q is not json or text", c.Log.Format)
+	}
+	if c.Images.CacheDir == "" {
+		problem("images.cache_dir", "must be set")
+	}
+	if c.Images.CacheMaxMB < 1 {
+		problem("images.cache_max_mb", "must be at least 1")
+	}
+	if c.Images.MaxSourceMB < 1 {
+		problem("images.max_source_mb", "must be at least 1")
+	}
+	if c.Images.FetchTimeout <= 0 {
+		problem("images.fetch_timeout", "must be positive")
+	}
+	switch c.Storage.Backend {
+	case "local":
+	case "s3":
+		for key, value := range map[string]string{
+			"storage.s3.endpoint":   c.Storage.S3.Endpoint,
+			"storage.s3.bucket":     c.Storage.S3.Bucket,
+			"storage.s3.access_key": c.Storage.S3.AccessKey,
+			"storage.s3.secret_key": c.Storage.S3.SecretKey,
+		} {
+			if value == "" {
+				problem(key, "is required by the s3 backend")
+			}
+		}
+		if strings.Contains(c.Storage.S3.Endpoint, "://") {
+			problem("storage.s3.endpoint", "%q must be a host without scheme, see storage.s3.use_ssl", c.Storage.S3.Endpoint)
+		}
+	default:
+		problem("storage.backend", "unknown backend %q, want local or s3", c.Storage.Backend)
+	}
+	if c.Storage.MaxUploadMB < 1 {
+		problem("storage.max_upload_mb", "must be at least 1")
+	}
+	if c.LinkCheck.Interval < 0 {
+		problem("link_check.interval", "must not be negative")
+	}
+	if c.LinkCheck.Concurrency < 1 {
+		problem("link_check.concurrency", "must be at least 1")
+	}
+	if c.LinkCheck.Timeout <= 0 {
+		problem("link_check.timeout", "must be positive")
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &Error{problems}
+	}
+	return nil
+}
+
+// Redacted returns a copy of c with its secrets masked, for printing.
+func (c *Config) Redacted() *Config {
+	r := *c
+	for _, secret := range []*string{&r.DB.Password, &r.Auth.SessionSecret, &r.Auth.JWTSecret, &r.Storage.S3.SecretKey} {
+		if *secret != "" {
+			*secret = "[redacted]"
+		}
+	}
+	return &r
+}
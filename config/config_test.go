@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestDSN(t *testing.T) {
+	db := DB{User: "books", Password: "p@ss/w:rd?x=1&y", Host: "db", Port: 3306, Name: "adindopustaka"}
+	dsn, err := mysql.ParseDSN(db.DSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsn.User != db.User || dsn.Passwd != db.Password || dsn.Addr != "db:3306" || dsn.DBName != db.Name {
+		t.Errorf("DSN() = %q parses to %s:%s@%s/%s", db.DSN(), dsn.User, dsn.Passwd, dsn.Addr, dsn.DBName)
+	}
+	if !dsn.ParseTime || !dsn.ClientFoundRows {
+		t.Errorf("DSN() = %q, want parseTime and clientFoundRows", db.DSN())
+	}
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// binding ties a setting, named by its file key, to an environment variable
+// and to a flag named after the key.
+type binding struct {
+	key, env, usage string
+	field           func(c *Config) interface{}
+}
+
+func (b binding) flag() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(b.key)
+}
+
+var bindings = []binding{
+	{"server.port", "PORT", "HTTP port", func(c *Config) interface{} { return &c.Server.Port }},
+	{"server.grpc_port", "GRPC_PORT", "gRPC port, 0 disables gRPC", func(c *Config) interface{} { return &c.Server.GRPCPort }},
+	{"server.cover_dir", "COVER_DIR", "directory of uploaded covers with the local storage backend", func(c *Config) interface{} { return &c.Server.CoverDir }},
+	{"server.public_url", "PUBLIC_URL", "absolute URL of the site, for canonical links", func(c *Config) interface{} { return &c.Server.PublicURL }},
+	{"server.allowed_hosts", "ALLOWED_HOSTS", "comma separated hosts the links may use when public_url is empty", func(c *Config) interface{} { return &c.Server.AllowedHosts }},
+	{"server.readiness_timeout", "READINESS_TIMEOUT", "deadline of each readiness check", func(c *Config) interface{} { return &c.Server.ReadinessTimeout }},
+	{"server.drain_delay", "DRAIN_DELAY", "how long readiness fails before shutting down", func(c *Config) interface{} { return &c.Server.DrainDelay }},
+	{"server.drain_timeout", "DRAIN_TIMEOUT", "how long in-flight requests get to finish on shutdown", func(c *Config) interface{} { return &c.Server.DrainTimeout }},
+	{"server.tls_cert_file", "TLS_CERT_FILE", "PEM certificate, enables HTTPS", func(c *Config) interface{} { return &c.Server.TLSCertFile }},
+	{"server.tls_key_file", "TLS_KEY_FILE", "PEM private key of the certificate", func(c *Config) interface{} { return &c.Server.TLSKeyFile }},
+	{"server.http2", "HTTP2", "negotiate HTTP/2 over TLS", func(c *Config) interface{} { return &c.Server.HTTP2 }},
+	{"server.trusted_proxies", "TRUSTED_PROXIES", "comma separated CIDRs allowed to set X-Forwarded-For", func(c *Config) interface{} { return &c.Server.TrustedProxies }},
+
+	{"db.user", "DB_USER", "database user", func(c *Config) interface{} { return &c.DB.User }},
+	{"db.password", "DB_PASSWORD", "database password", func(c *Config) interface{} { return &c.DB.Password }},
+	{"db.password_file", "DB_PASSWORD_FILE", "file holding the database password", func(c *Config) interface{} { return &c.DB.PasswordFile }},
+	{"db.host", "DB_HOST", "database host", func(c *Config) interface{} { return &c.DB.Host }},
+	{"db.port", "DB_PORT", "database port", func(c *Config) interface{} { return &c.DB.Port }},
+	{"db.name", "DB_DBNAME", "database name", func(c *Config) interface{} { return &c.DB.Name }},
+	{"db.max_open_conns", "DB_MAX_OPEN_CONNS", "maximum open connections, 0 is unlimited", func(c *Config) interface{} { return &c.DB.MaxOpenConns }},
+	{"db.max_idle_conns", "DB_MAX_IDLE_CONNS", "maximum idle connections", func(c *Config) interface{} { return &c.DB.MaxIdleConns }},
+	{"db.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "maximum connection age, 0 is unlimited", func(c *Config) interface{} { return &c.DB.ConnMaxLifetime }},
+	{"db.connect_timeout", "DB_CONNECT_TIMEOUT", "how long to wait for the database at startup", func(c *Config) interface{} { return &c.DB.ConnectTimeout }},
+	{"db.read_retries", "DB_READ_RETRIES", "retries of reads failing with a transient error", func(c *Config) interface{} { return &c.DB.ReadRetries }},
+
+	{"auth.session_secret", "SESSION_SECRET", "admin session signing secret, random when unset", func(c *Config) interface{} { return &c.Auth.SessionSecret }},
+	{"auth.session_secret_file", "SESSION_SECRET_FILE", "file holding the session secret", func(c *Config) interface{} { return &c.Auth.SessionSecretFile }},
+	{"auth.session_max_age", "SESSION_MAX_AGE", "how long a console session lasts", func(c *Config) interface{} { return &c.Auth.SessionMaxAge }},
+	{"auth.session_secure", "SESSION_SECURE", "send the session cookie over HTTPS only", func(c *Config) interface{} { return &c.Auth.SessionSecure }},
+	{"auth.jwt_secret", "JWT_SECRET", "HS256 JWT secret", func(c *Config) interface{} { return &c.Auth.JWTSecret }},
+	{"auth.jwt_secret_file", "JWT_SECRET_FILE", "file holding the HS256 JWT secret", func(c *Config) interface{} { return &c.Auth.JWTSecretFile }},
+	{"auth.jwt_public_key_file", "JWT_PUBLIC_KEY_FILE", "PEM file of the RS256 JWT public key", func(c *Config) interface{} { return &c.Auth.JWTPublicKeyFile }},
+
+	{"rate_limit.store", "RATE_LIMIT_STORE", "memory or mysql to share limits between replicas", func(c *Config) interface{} { return &c.RateLimit.Store }},
+	{"rate_limit.api.rate", "RATE_LIMIT_API", "requests per second of the public API, 0 disables", func(c *Config) interface{} { return &c.RateLimit.API.Rate }},
+	{"rate_limit.api.burst", "RATE_LIMIT_API_BURST", "bucket size of the public API", func(c *Config) interface{} { return &c.RateLimit.API.Burst }},
+	{"rate_limit.admin.rate", "RATE_LIMIT_ADMIN", "requests per second of the admin routes, 0 disables", func(c *Config) interface{} { return &c.RateLimit.Admin.Rate }},
+	{"rate_limit.admin.burst", "RATE_LIMIT_ADMIN_BURST", "bucket size of the admin routes", func(c *Config) interface{} { return &c.RateLimit.Admin.Burst }},
+	{"rate_limit.images.rate", "RATE_LIMIT_IMAGES", "requests per second of the /img covers, 0 disables", func(c *Config) interface{} { return &c.RateLimit.Images.Rate }},
+	{"rate_limit.images.burst", "RATE_LIMIT_IMAGES_BURST", "bucket size of the /img covers", func(c *Config) interface{} { return &c.RateLimit.Images.Burst }},
+
+	{"query_timeout.get", "QUERY_TIMEOUT_GET", "query deadline of single resources, 0 disables", func(c *Config) interface{} { return &c.QueryTimeout.Get }},
+	{"query_timeout.list", "QUERY_TIMEOUT_LIST", "query deadline of paginated collections, 0 disables", func(c *Config) interface{} { return &c.QueryTimeout.List }},
+	{"query_timeout.graphql", "QUERY_TIMEOUT_GRAPHQL", "query deadline of GraphQL requests, 0 disables", func(c *Config) interface{} { return &c.QueryTimeout.GraphQL }},
+	{"query_timeout.admin", "QUERY_TIMEOUT_ADMIN", "query deadline of the admin routes, 0 disables", func(c *Config) interface{} { return &c.QueryTimeout.Admin }},
+
+	{"tracing.endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTLP/HTTP collector URL, empty disables tracing", func(c *Config) interface{} { return &c.Tracing.Endpoint }},
+	{"tracing.service_name", "OTEL_SERVICE_NAME", "service name of the spans", func(c *Config) interface{} { return &c.Tracing.ServiceName }},
+	{"tracing.sample_ratio", "OTEL_TRACES_SAMPLER_ARG", "share of new traces recorded", func(c *Config) interface{} { return &c.Tracing.SampleRatio }},
+	{"log.level", "LOG_LEVEL", "minimum level logged: debug, info, warn or error", func(c *Config) interface{} { return &c.Log.Level }},
+	{"log.format", "LOG_FORMAT", "log output: json or text", func(c *Config) interface{} { return &c.Log.Format }},
+
+	{"images.cache_dir", "IMAGE_CACHE_DIR", "directory of the resized cover cache", func(c *Config) interface{} { return &c.Images.CacheDir }},
+	{"images.cache_max_mb", "IMAGE_CACHE_MAX_MB", "size limit of the resized cover cache", func(c *Config) interface{} { return &c.Images.CacheMaxMB }},
+	{"images.fetch_timeout", "IMAGE_FETCH_TIMEOUT", "deadline of fetching a source cover", func(c *Config) interface{} { return &c.Images.FetchTimeout }},
+	{"images.max_source_mb", "IMAGE_MAX_SOURCE_MB", "size limit of a source cover", func(c *Config) interface{} { return &c.Images.MaxSourceMB }},
+
+	{"storage.backend", "STORAGE_BACKEND", "where uploaded covers are kept: local or s3", func(c *Config) interface{} { return &c.Storage.Backend }},
+	{"storage.max_upload_mb", "COVER_MAX_UPLOAD_MB", "size limit of an uploaded cover", func(c *Config) interface{} { return &c.Storage.MaxUploadMB }},
+	{"storage.s3.endpoint", "S3_ENDPOINT", "host and port of the S3 service", func(c *Config) interface{} { return &c.Storage.S3.Endpoint }},
+	{"storage.s3.region", "S3_REGION", "region of the bucket", func(c *Config) interface{} { return &c.Storage.S3.Region }},
+	{"storage.s3.bucket", "S3_BUCKET", "bucket of the uploaded covers", func(c *Config) interface{} { return &c.Storage.S3.Bucket }},
+	{"storage.s3.access_key", "S3_ACCESS_KEY", "S3 access key ID", func(c *Config) interface{} { return &c.Storage.S3.AccessKey }},
+	{"storage.s3.secret_key", "S3_SECRET_KEY", "S3 secret access key", func(c *Config) interface{} { return &c.Storage.S3.SecretKey }},
+	{"storage.s3.secret_key_file", "S3_SECRET_KEY_FILE", "file holding the S3 secret access key", func(c *Config) interface{} { return &c.Storage.S3.SecretKeyFile }},
+	{"storage.s3.use_ssl", "S3_USE_SSL", "reach the S3 service over HTTPS", func(c *Config) interface{} { return &c.Storage.S3.UseSSL }},
+
+	{"link_check.interval", "LINK_CHECK_INTERVAL", "time between background link checks, 0 disables", func(c *Config) interface{} { return &c.LinkCheck.Interval }},
+	{"link_check.concurrency", "LINK_CHECK_CONCURRENCY", "links checked at once", func(c *Config) interface{} { return &c.LinkCheck.Concurrency }},
+	{"link_check.timeout", "LINK_CHECK_TIMEOUT", "deadline of checking one link", func(c *Config) interface{} { return &c.LinkCheck.Timeout }},
+}
+
+func init() {
+	groups := []struct {
+		name   string
+		policy func(c *Config) *Policy
+	}{
+		{"public", func(c *Config) *Policy { return &c.CORS.Public }},
+		{"write", func(c *Config) *Policy { return &c.CORS.Write }},
+		{"admin", func(c *Config) *Policy { return &c.CORS.Admin }},
+	}
+	for _, group := range groups {
+		policy := group.policy
+		key, env := "cors."+group.name+".", "CORS_"+strings.ToUpper(group.name)+"_"
+		bindings = append(bindings,
+			binding{key + "origins", env + "ORIGINS", "comma separated allowed origins, none disables", func(c *Config) interface{} { return &policy(c).Origins }},
+			binding{key + "methods", env + "METHODS", "comma separated allowed methods", func(c *Config) interface{} { return &policy(c).Methods }},
+			binding{key + "headers", env + "HEADERS", "comma separated allowed request headers", func(c *Config) interface{} { return &policy(c).Headers }},
+			binding{key + "credentials", env + "CREDENTIALS", "allow cookies and authorization headers", func(c *Config) interface{} { return &policy(c).Credentials }},
+			binding{key + "max_age", env + "MAX_AGE", "preflight cache duration", func(c *Config) interface{} { return &policy(c).MaxAge }},
+		)
+	}
+}
+
+func bindingOf(key string) (binding, bool) {
+	for _, b := range bindings {
+		if b.key == key {
+			return b, true
+		}
+	}
+	return binding{}, false
+}
+
+// list is a comma separated flag value, where "none" is the empty list.
+type list []string
+
+func (l *list) String() string { return strings.Join(*l, ",") }
+
+func (l *list) Set(s string) error {
+	*l = []string{}
+	if s == "none" {
+		return nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func (c *Config) flags(fs *flag.FlagSet) {
+	for _, b := range bindings {
+		name, usage := b.flag(), b.usage+" [$"+b.env+"]"
+		switch p := b.field(c).(type) {
+		case *string:
+			fs.StringVar(p, name, *p, usage)
+		case *int:
+			fs.IntVar(p, name, *p, usage)
+		case *float64:
+			fs.Float64Var(p, name, *p, usage)
+		case *bool:
+			fs.BoolVar(p, name, *p, usage)
+		case *time.Duration:
+			fs.DurationVar(p, name, *p, usage)
+		case *[]string:
+			fs.Var((*list)(p), name, usage)
+		}
+	}
+}
+
+// Load builds the configuration from the defaults, the file named by -config
+// or CONFIG_FILE, the environment and args, the command line without the
+// program name. It returns the arguments left after the flags.
+func Load(args []string) (*Config, []string, error) {
+	path := os.Getenv("CONFIG_FILE")
+
+	// The first pass only finds the file, which the flags override.
+	fs := flag.NewFlagSet("adindopustaka", flag.ContinueOnError)
+	fs.StringVar(&path, "config", path, "YAML or TOML configuration file [$CONFIG_FILE]")
+	Default().flags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	c := Default()
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fs = flag.NewFlagSet("adindopustaka", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.String("config", path, "")
+	c.flags(fs)
+	for _, b := range bindings {
+		if v := os.Getenv(b.env); v != "" {
+			if err := fs.Set(b.flag(), v); err != nil {
+				return nil, nil, fmt.Errorf("%s: invalid value %q", b.env, v)
+			}
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.loadSecrets(); err != nil {
+		return nil, nil, err
+	}
+	return c, fs.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown setting %s", path, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("%s: unknown format, want .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// loadSecrets reads the secrets given as files, trimming the trailing newline
+// most editors and secret stores add.
+func (c *Config) loadSecrets() error {
+	secrets := []struct {
+		key, file string
+		secret    *string
+	}{
+		{"db.password", c.DB.PasswordFile, &c.DB.Password},
+		{"auth.session_secret", c.Auth.SessionSecretFile, &c.Auth.SessionSecret},
+		{"auth.jwt_secret", c.Auth.JWTSecretFile, &c.Auth.JWTSecret},
+		{"storage.s3.secret_key", c.Storage.S3.SecretKeyFile, &c.Storage.S3.SecretKey},
+	}
+	for _, s := range secrets {
+		if s.file == "" {
+			continue
+		}
+		if *s.secret != "" {
+			return fmt.Errorf("%s and %s_file are mutually exclusive", s.key, s.key)
+		}
+		data, err := ioutil.ReadFile(s.file)
+		if err != nil {
+			return fmt.Errorf("%s_file: %v", s.key, err)
+		}
+		*s.secret = strings.TrimRight(string(data), "\r\n")
+	}
+	return nil
+}
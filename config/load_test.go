@@ -0,0 +1,161 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearEnv unsets the variables Load reads, for the environment of the test
+// run not to leak in.
+func clearEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	for _, b := range bindings {
+		t.Setenv(b.env, "")
+	}
+}
+
+// writeFile writes content to name in a temporary directory.
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yaml := writeFile(t, "config.yaml", "server:\n  port: 9000\ndb:\n  host: file-db\nlog:\n  level: debug\n")
+	toml := writeFile(t, "config.toml", "[server]\nport = 9000\n[db]\nhost = \"file-db\"\n[log]\nlevel = \"debug\"\n")
+
+	for _, tt := range []struct {
+		name string
+		env  map[string]string
+		args []string
+		port int
+		host string
+	}{
+		{"defaults", nil, nil, 8080, ""},
+		{"file", map[string]string{"CONFIG_FILE": yaml}, nil, 9000, "file-db"},
+		{"toml file", nil, []string{"-config", toml}, 9000, "file-db"},
+		{"env over file", map[string]string{"CONFIG_FILE": yaml, "PORT": "9100"}, nil, 9100, "file-db"},
+		{"flags over env", map[string]string{"CONFIG_FILE": yaml, "PORT": "9100", "DB_HOST": "env-db"}, []string{"-server-port", "9200"}, 9200, "env-db"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			c, args, err := Load(append(tt.args, "serve"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Server.Port != tt.port || c.DB.Host != tt.host {
+				t.Errorf("port, host = %d, %q, want %d, %q", c.Server.Port, c.DB.Host, tt.port, tt.host)
+			}
+			// the settings no layer sets keep their default
+			if c.DB.Port != 3306 || c.Log.Format != "json" {
+				t.Errorf("db.port, log.format = %d, %q, want the defaults", c.DB.Port, c.Log.Format)
+			}
+			if len(args) != 1 || args[0] != "serve" {
+				t.Errorf("args = %v, want [serve]", args)
+			}
+		})
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{"unknown yaml setting", map[string]string{"CONFIG_FILE": writeFile(t, "c.yaml", "server:\n  prot: 1\n")}, nil},
+		{"unknown toml setting", map[string]string{"CONFIG_FILE": writeFile(t, "c.toml", "[server]\nprot = 1\n")}, nil},
+		{"unknown format", map[string]string{"CONFIG_FILE": writeFile(t, "c.json", "{}")}, nil},
+		{"invalid env", map[string]string{"PORT": "eighty"}, nil},
+		{"unknown flag", nil, []string{"-prot", "1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, _, err := Load(tt.args); err == nil {
+				t.Error("Load succeeded")
+			}
+		})
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db", "s3cr3t\r\n"))
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt", "signing key\n"))
+	c, _, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.Password != "s3cr3t" || c.Auth.JWTSecret != "signing key" {
+		t.Errorf("secrets = %q, %q, want them without the newline", c.DB.Password, c.Auth.JWTSecret)
+	}
+
+	t.Setenv("DB_PASSWORD", "inline")
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Errorf("Load with db.password and its file = %v, want them exclusive", err)
+	}
+
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, _, err := Load(nil); err == nil || !strings.HasPrefix(err.Error(), "db.password_file:") {
+		t.Errorf("Load with a missing secret file = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		c := Default()
+		c.DB.User, c.DB.Host, c.DB.Name = "books", "db", "adindopustaka"
+		return c
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		change func(c *Config)
+		keys   []string
+	}{
+		{"missing database", func(c *Config) { c.DB.User, c.DB.Host = "", "" }, []string{"db.user", "db.host"}},
+		{"ports", func(c *Config) { c.Server.Port, c.Server.GRPCPort = 0, 70000 }, []string{"server.port", "server.grpc_port"}},
+		{"same ports", func(c *Config) { c.Server.GRPCPort = c.Server.Port }, []string{"server.grpc_port"}},
+		{"half of TLS", func(c *Config) { c.Server.TLSCertFile = "cert.pem" }, []string{"server.tls_key_file"}},
+		{"proxies", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy"} }, []string{"server.trusted_proxies"}},
+		{"idle over open", func(c *Config) { c.DB.MaxIdleConns = 30 }, []string{"db.max_idle_conns"}},
+		{"rate store", func(c *Config) { c.RateLimit.Store = "redis" }, []string{"rate_limit.store"}},
+		{"burst", func(c *Config) { c.RateLimit.API.Burst = 0 }, []string{"rate_limit.api.burst"}},
+		{"credentials for every origin", func(c *Config) { c.CORS.Public.Credentials = true }, []string{"cors.public.credentials"}},
+		{"log", func(c *Config) { c.Log.Level, c.Log.Format = "loud", "xml" }, []string{"log.level", "log.format"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.change(c)
+			err, _ := c.Validate().(*Error)
+			if err == nil || len(err.Problems) != len(tt.keys) {
+				t.Fatalf("Validate() = %v, want problems with %v", err, tt.keys)
+			}
+			// every problem at once, each telling how to set the key
+			for _, key := range tt.keys {
+				found := false
+				for _, p := range err.Problems {
+					found = found || strings.HasPrefix(p, key+": ")
+				}
+				if !found {
+					t.Errorf("Validate() = %v, want a problem with %s", err, key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/kautsarady/adindopustaka/api"
+	"github.com/kautsarady/adindopustaka/config"
+)
+
+// corsPolicies sets the CORS policy of each route group. Policies without
+// origins are left nil, which rejects cross origin requests.
+func corsPolicies(cfg *config.Config, opts *api.Options) {
+	opts.PublicCORS = corsPolicy(cfg.CORS.Public)
+	opts.WriteCORS = corsPolicy(cfg.CORS.Write)
+	opts.AdminCORS = corsPolicy(cfg.CORS.Admin)
+}
+
+func corsPolicy(policy config.Policy) *cors.Config {
+	if len(policy.Origins) == 0 {
+		return nil
+	}
+
+	c := &cors.Config{
+		AllowMethods:     policy.Methods,
+		AllowHeaders:     policy.Headers,
+		AllowCredentials: policy.Credentials,
+		ExposeHeaders:    []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Deprecation", "Link"},
+		MaxAge:           policy.MaxAge,
+	}
+	for _, origin := range policy.Origins {
+		if origin == "*" {
+			c.AllowAllOrigins = true
+			continue
+		}
+		c.AllowOrigins = append(c.AllowOrigins, origin)
+		if strings.Contains(origin, "*") {
+			c.AllowWildcard = true
+		}
+	}
+	if c.AllowAllOrigins {
+		c.AllowOrigins = nil
+	}
+	return c
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/kautsarady/adindopustaka/api"
+	"github.com/kautsarady/adindopustaka/config"
+	"github.com/kautsarady/adindopustaka/health"
+	"github.com/kautsarady/adindopustaka/storage"
+)
+
+// coverStorage sets where the uploaded covers are kept, adding a readiness
+// check of the bucket when it is remote.
+func coverStorage(cfg *config.Config, opts *api.Options) error {
+	opts.MaxCoverBytes = int64(cfg.Storage.MaxUploadMB) << 20
+
+	switch cfg.Storage.Backend {
+	case "local":
+		opts.Covers = storage.Local{Dir: cfg.Server.CoverDir}
+	case "s3":
+		s3, err := storage.NewS3(storage.S3Options{
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			Region:    cfg.Storage.S3.Region,
+			Bucket:    cfg.Storage.S3.Bucket,
+			AccessKey: cfg.Storage.S3.AccessKey,
+			SecretKey: cfg.Storage.S3.SecretKey,
+			UseSSL:    cfg.Storage.S3.UseSSL,
+		})
+		if err != nil {
+			return err
+		}
+		opts.Covers = s3
+		opts.Health.Checks = append(opts.Health.Checks, health.Check{Name: "storage", Run: s3.Ping})
+	}
+	return nil
+}
@@ -14,7 +14,7 @@ import (
 var doc = `{
     "swagger": "2.0",
     "info": {
-        "description": "Adindopustaka API documentation",
+        "description": "Adindopustaka API documentation\nThe API is versioned under /api/v1 and /api/v2. The unversioned paths serve the version the Accept header asks for, v1 by default. v2 wraps the responses in a data envelope, with the pagination in its metadata, dates the books and answers empty pages rather than 404.",
         "title": "Adindopustaka API",
         "contact": {
             "name": "kautsarady",
@@ -26,6 +26,128 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/admin/broken-links": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the book links found broken by the last link check.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Broken Links",
+                "operationId": "get-broken-links",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page link count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.LinkCheck"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/key": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All API Key",
+                "operationId": "get-all-key",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Key"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
         "/api/author": {
             "get": {
                 "consumes": [
@@ -37,6 +159,12 @@ var doc = `{
                 "summary": "Get All Author",
                 "operationId": "get-all-author",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
                     {
                         "type": "string",
                         "format": "string",
@@ -47,9 +175,16 @@ var doc = `{
                     {
                         "type": "string",
                         "format": "string",
-                        "description": "per_page product count (default=20)",
+                        "description": "per_page product count (default=20, max=100)",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -66,14 +201,28 @@ var doc = `{
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
@@ -90,6 +239,12 @@ var doc = `{
                 "summary": "Get Author By ID",
                 "operationId": "get-all-getAuthorByID",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
                     {
                         "type": "string",
                         "description": "author id to search",
@@ -107,9 +262,16 @@ var doc = `{
                     {
                         "type": "string",
                         "format": "string",
-                        "description": "per_page product count of the item books (default=20)",
+                        "description": "per_page product count of the item books (default=20, max=100)",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -124,14 +286,28 @@ var doc = `{
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
@@ -146,7 +322,1308 @@ var doc = `{
                     "application/json"
                 ],
                 "summary": "Get All Book",
-                "operationId": "get-all-book",
+                "operationId": "get-all-book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated relations to embed (authors,categories,tags)",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "sort order (id,-id,title,-title)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Book"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/book/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Book By ID",
+                "operationId": "get-all-getBookByID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "book id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/book/{id}/cover": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stores a JPEG, PNG, GIF or WebP cover and points the book image_url at it.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Upload Book Cover",
+                "operationId": "upload-book-cover",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "book id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "cover image",
+                        "name": "cover",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/category": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Category",
+                "operationId": "get-all-category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/category/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Category By ID",
+                "operationId": "get-all-getCategoryByID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "category id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number of the item books (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count of the item books (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Tag",
+                "operationId": "get-all-tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Tag By ID",
+                "operationId": "get-all-getTagByID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "application/vnd.adindopustaka.v2+json or application/json; version=2 for v2, v1 otherwise",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tag id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number of the item books (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count of the item books (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/author": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Author",
+                "operationId": "get-all-author-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v1/author/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Author By ID",
+                "operationId": "get-all-getAuthorByID-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "author id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number of the item books (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count of the item books (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v1/book": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Book",
+                "operationId": "get-all-book-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated relations to embed (authors,categories,tags)",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "sort order (id,-id,title,-title)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Book"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v1/book/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Book By ID",
+                "operationId": "get-all-getBookByID-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "book id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v1/category": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Category",
+                "operationId": "get-all-category-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v1/category/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Category By ID",
+                "operationId": "get-all-getCategoryByID-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "category id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number of the item books (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count of the item books (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v1/tag": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Tag",
+                "operationId": "get-all-tag-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v1/tag/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Tag By ID",
+                "operationId": "get-all-getTagByID-v1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tag id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number of the item books (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count of the item books (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/model.Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.HTTPError"
+                        }
+                    }
+                },
+                "deprecated": true
+            }
+        },
+        "/api/v2/author": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Author",
+                "operationId": "get-all-author-v2",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/api.itemPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/author/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get Author By ID",
+                "operationId": "get-all-getAuthorByID-v2",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "author id to search",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "page number of the item books (default=1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "per_page product count of the item books (default=20, max=100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/api.itemData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/book": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get All Book",
+                "operationId": "get-all-book-v2",
                 "parameters": [
                     {
                         "type": "string",
@@ -158,39 +1635,58 @@ var doc = `{
                     {
                         "type": "string",
                         "format": "string",
-                        "description": "per_page product count (default=20)",
+                        "description": "per_page product count (default=20, max=100)",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated relations to embed (authors,categories,tags)",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "sort order (id,-id,title,-title)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Book"
-                            }
+                            "type": "object",
+                            "$ref": "#/definitions/api.bookPage"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "504": {
+                        "description": "Gateway Timeout",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/book/{id}": {
+        "/api/v2/book/{id}": {
             "get": {
                 "consumes": [
                     "application/json"
@@ -199,7 +1695,7 @@ var doc = `{
                     "application/json"
                 ],
                 "summary": "Get Book By ID",
-                "operationId": "get-all-getBookByID",
+                "operationId": "get-all-getBookByID-v2",
                 "parameters": [
                     {
                         "type": "string",
@@ -207,6 +1703,13 @@ var doc = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. id,title,authors.name), including the relations they select",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -214,27 +1717,34 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/model.Book"
+                            "$ref": "#/definitions/api.bookData"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/category": {
+        "/api/v2/category": {
             "get": {
                 "consumes": [
                     "application/json"
@@ -243,7 +1753,7 @@ var doc = `{
                     "application/json"
                 ],
                 "summary": "Get All Category",
-                "operationId": "get-all-category",
+                "operationId": "get-all-category-v2",
                 "parameters": [
                     {
                         "type": "string",
@@ -255,39 +1765,44 @@ var doc = `{
                     {
                         "type": "string",
                         "format": "string",
-                        "description": "per_page product count (default=20)",
+                        "description": "per_page product count (default=20, max=100)",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Item"
-                            }
+                            "type": "object",
+                            "$ref": "#/definitions/api.itemPage"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "504": {
+                        "description": "Gateway Timeout",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/category/{id}": {
+        "/api/v2/category/{id}": {
             "get": {
                 "consumes": [
                     "application/json"
@@ -296,7 +1811,7 @@ var doc = `{
                     "application/json"
                 ],
                 "summary": "Get Category By ID",
-                "operationId": "get-all-getCategoryByID",
+                "operationId": "get-all-getCategoryByID-v2",
                 "parameters": [
                     {
                         "type": "string",
@@ -315,9 +1830,16 @@ var doc = `{
                     {
                         "type": "string",
                         "format": "string",
-                        "description": "per_page product count of the item books (default=20)",
+                        "description": "per_page product count of the item books (default=20, max=100)",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -325,27 +1847,34 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/model.Item"
+                            "$ref": "#/definitions/api.itemData"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/tag": {
+        "/api/v2/tag": {
             "get": {
                 "consumes": [
                     "application/json"
@@ -354,7 +1883,7 @@ var doc = `{
                     "application/json"
                 ],
                 "summary": "Get All Tag",
-                "operationId": "get-all-tag",
+                "operationId": "get-all-tag-v2",
                 "parameters": [
                     {
                         "type": "string",
@@ -366,39 +1895,44 @@ var doc = `{
                     {
                         "type": "string",
                         "format": "string",
-                        "description": "per_page product count (default=20)",
+                        "description": "per_page product count (default=20, max=100)",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Item"
-                            }
+                            "type": "object",
+                            "$ref": "#/definitions/api.itemPage"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "504": {
+                        "description": "Gateway Timeout",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/tag/{id}": {
+        "/api/v2/tag/{id}": {
             "get": {
                 "consumes": [
                     "application/json"
@@ -407,7 +1941,7 @@ var doc = `{
                     "application/json"
                 ],
                 "summary": "Get Tag By ID",
-                "operationId": "get-all-getTagByID",
+                "operationId": "get-all-getTagByID-v2",
                 "parameters": [
                     {
                         "type": "string",
@@ -426,9 +1960,16 @@ var doc = `{
                     {
                         "type": "string",
                         "format": "string",
-                        "description": "per_page product count of the item books (default=20)",
+                        "description": "per_page product count of the item books (default=20, max=100)",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "comma separated fields to return, nested with dots (e.g. name,books.title)",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -436,36 +1977,229 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/model.Item"
+                            "$ref": "#/definitions/api.itemData"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/httputil.HTTPError"
+                            "$ref": "#/definitions/httputil.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Gateway Timeout",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/httputil.Problem"
                         }
                     }
                 }
             }
         }
     },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
+    },
     "definitions": {
+        "api.bookData": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/api.bookV2"
+                }
+            }
+        },
+        "api.bookPage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.bookV2"
+                    }
+                },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/api.metadata"
+                }
+            }
+        },
+        "api.bookV2": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Item"
+                    }
+                },
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Item"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "gramed_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Item"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.itemData": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/api.itemV2"
+                }
+            }
+        },
+        "api.itemPage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.itemV2"
+                    }
+                },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/api.metadata"
+                }
+            }
+        },
+        "api.itemV2": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.bookV2"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.metadata": {
+            "type": "object",
+            "properties": {
+                "entity": {
+                    "type": "string"
+                },
+                "next": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "prev": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httputil.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "is required"
+                }
+            }
+        },
         "httputil.HTTPError": {
             "type": "object",
             "properties": {
                 "code": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 404
                 },
                 "message": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "no corresponding data found"
+                }
+            }
+        },
+        "httputil.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "no corresponding data found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/httputil.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/book/42"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
                 }
             }
         },
@@ -523,6 +2257,65 @@ var doc = `{
                     "type": "string"
                 }
             }
+        },
+        "model.Key": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.LinkCheck": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "broken": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "checked_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "image_url"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Laskar Pelangi"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://cdn.gramedia.com/uploads/items/laskar.jpg"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,93 @@
+// Package health runs the readiness checks of the service.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check verifies that a dependency of the service is usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Checker runs every check concurrently, each bounded by Timeout (one second
+// when zero). Once drained, it reports the service as not ready whatever the
+// checks say, so that load balancers stop routing to it before shutdown.
+type Checker struct {
+	Checks  []Check
+	Timeout time.Duration
+
+	draining int32
+}
+
+// Status .
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusDraining = "draining"
+)
+
+// Report is the outcome of every check.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Ready reports whether the service can take traffic.
+func (r Report) Ready() bool { return r.Status == StatusOK }
+
+// Drain marks the service as not ready for good.
+func (c *Checker) Drain() { atomic.StoreInt32(&c.draining, 1) }
+
+// Draining reports whether Drain was called.
+func (c *Checker) Draining() bool { return atomic.LoadInt32(&c.draining) == 1 }
+
+// Run runs the checks. A draining service still runs them, for the detail.
+func (c *Checker) Run(ctx context.Context) Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	report := Report{Status: StatusOK, Checks: make([]Result, len(c.Checks))}
+	var wg sync.WaitGroup
+	for i, check := range c.Checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(ctx)
+			result := Result{Name: check.Name, Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status, result.Error = StatusFail, err.Error()
+			}
+			report.Checks[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	if c.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
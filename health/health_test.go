@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	ok := Check{Name: "ok", Run: func(context.Context) error { return nil }}
+	down := Check{Name: "down", Run: func(context.Context) error { return errors.New("connection refused") }}
+	slow := Check{Name: "slow", Run: func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Minute):
+			return nil
+		}
+	}}
+
+	for _, tt := range []struct {
+		name   string
+		checks []Check
+		status string
+		failed string
+	}{
+		{"none", nil, StatusOK, ""},
+		{"passing", []Check{ok}, StatusOK, ""},
+		{"failing", []Check{ok, down}, StatusFail, "down"},
+		{"timing out", []Check{slow, ok}, StatusFail, "slow"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Checker{Checks: tt.checks, Timeout: 10 * time.Millisecond}
+			report := c.Run(context.Background())
+			if report.Status != tt.status || report.Ready() != (tt.status == StatusOK) {
+				t.Errorf("status = %s, want %s", report.Status, tt.status)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("%d results, want %d", len(report.Checks), len(tt.checks))
+			}
+			// the results keep the order of the checks
+			for i, result := range report.Checks {
+				want := StatusOK
+				if result.Name == tt.failed {
+					want = StatusFail
+				}
+				if result.Name != tt.checks[i].Name || result.Status != want || (want == StatusFail) == (result.Error == "") {
+					t.Errorf("result %d = %+v, want %s %s", i, result, tt.checks[i].Name, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDrain(t *testing.T) {
+	ran := false
+	c := &Checker{Checks: []Check{{Name: "ok", Run: func(context.Context) error { ran = true; return nil }}}}
+	if c.Draining() {
+		t.Fatal("Draining() before Drain")
+	}
+	c.Drain()
+	report := c.Run(context.Background())
+	if !c.Draining() || report.Status != StatusDraining || report.Ready() {
+		t.Errorf("status after Drain = %s, want %s", report.Status, StatusDraining)
+	}
+	if !ran || report.Checks[0].Status != StatusOK {
+		t.Errorf("checks after Drain = %+v, want them still run", report.Checks)
+	}
+}
@@ -1,18 +1,91 @@
 package httputil
 
-import "github.com/gin-gonic/gin"
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
 
-// NewError .
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/logging"
+)
+
+// VersionKey is the gin context key holding the negotiated API version.
+const VersionKey = "api_version"
+
+// LegacyKey is the gin context key marking the requests of the v1 API, whose
+// errors keep the HTTPError shape they had before the problem details.
+const LegacyKey = "legacy_errors"
+
+// ProblemType is the media type of error responses, see RFC 7807.
+const ProblemType = "application/problem+json"
+
+// NewError responds with err. Server errors are logged along with the
+// underlying errors attached to ctx beforehand, which are not exposed.
 func NewError(ctx *gin.Context, status int, err error) {
-	er := HTTPError{
-		Code:    status,
-		Message: err.Error(),
+	problem(ctx, status, err.Error(), nil)
+}
+
+// NewInvalid responds with 400 listing the rejected fields.
+func NewInvalid(ctx *gin.Context, detail string, fields []FieldError) {
+	problem(ctx, http.StatusBadRequest, detail, fields)
+}
+
+func problem(ctx *gin.Context, status int, detail string, fields []FieldError) {
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx.Request.Context()).Error(detail,
+			"status", status, "errors", ctx.Errors.Errors())
+	}
+
+	if ctx.GetBool(LegacyKey) {
+		ctx.JSON(status, HTTPError{Code: status, Message: detail})
+		return
 	}
-	ctx.JSON(status, er)
+
+	body, err := json.Marshal(Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request.URL.Path,
+		Code:     Reason(status),
+		Errors:   fields,
+	})
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	ctx.Data(status, ProblemType, body)
 }
 
-// HTTPError .
+// Reason returns the machine readable error code of an HTTP status,
+// e.g. "not_found" for 404.
+func Reason(status int) string {
+	return strings.Replace(strings.ToLower(http.StatusText(status)), " ", "_", -1)
+}
+
+// Problem is the body of every error response, an RFC 7807 problem details
+// object.
+type Problem struct {
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail" example:"no corresponding data found"`
+	Instance string `json:"instance" example:"/api/book/42"`
+
+	// Code is the machine readable form of Status, e.g. "not_found".
+	Code string `json:"code" example:"not_found"`
+	// Errors lists the rejected fields of a validation error.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// HTTPError is the body of the error responses of the v1 API.
 type HTTPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int    `json:"code" example:"404"`
+	Message string `json:"message" example:"no corresponding data found"`
+}
+
+// FieldError describes why the value of a field was rejected.
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Message string `json:"message" example:"is required"`
 }
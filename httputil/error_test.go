@@ -0,0 +1,71 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tt := range []struct {
+		name   string
+		legacy bool
+		status int
+		want   string
+	}{
+		{"problem", false, http.StatusNotFound, `{"type":"about:blank","title":"Not Found","status":404,"detail":"no book","instance":"/api/book/1","code":"not_found"}`},
+		{"legacy", true, http.StatusNotFound, `{"code":404,"message":"no book"}`},
+		{"server error", false, http.StatusServiceUnavailable, `{"type":"about:blank","title":"Service Unavailable","status":503,"detail":"no book","instance":"/api/book/1","code":"service_unavailable"}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest("GET", "/api/book/1", nil)
+			ctx.Set(LegacyKey, tt.legacy)
+			NewError(ctx, tt.status, errors.New("no book"))
+
+			if w.Code != tt.status || w.Body.String() != tt.want {
+				t.Errorf("NewError = %d %s, want %d %s", w.Code, w.Body, tt.status, tt.want)
+			}
+			contentType := ProblemType
+			if tt.legacy {
+				contentType = "application/json; charset=utf-8"
+			}
+			if got := w.Header().Get("Content-Type"); got != contentType {
+				t.Errorf("Content-Type = %s, want %s", got, contentType)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest("POST", "/api/book", nil)
+	NewInvalid(ctx, "invalid book", []FieldError{{Field: "title", Message: "is required"}})
+
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || problem.Code != "bad_request" || len(problem.Errors) != 1 || problem.Errors[0].Field != "title" {
+		t.Errorf("NewInvalid = %d %s", w.Code, w.Body)
+	}
+}
+
+func TestReason(t *testing.T) {
+	for status, want := range map[int]string{
+		http.StatusNotFound:            "not_found",
+		http.StatusTooManyRequests:     "too_many_requests",
+		http.StatusInternalServerError: "internal_server_error",
+	} {
+		if got := Reason(status); got != want {
+			t.Errorf("Reason(%d) = %s, want %s", status, got, want)
+		}
+	}
+}
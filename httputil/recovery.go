@@ -0,0 +1,38 @@
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/logging"
+)
+
+// Recovery turns a panicking handler into a 500 problem response, keeping
+// the panic value and stack out of the response but not out of the log.
+func Recovery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			ctx.Error(fmt.Errorf("panic: %v\n%s", v, debug.Stack()))
+			if ctx.Writer.Written() {
+				logging.FromContext(ctx.Request.Context()).Error("panic after the response was written",
+					"errors", ctx.Errors.Errors())
+				ctx.Abort()
+				return
+			}
+			NewError(ctx, http.StatusInternalServerError, errors.New("internal server error"))
+			ctx.Abort()
+		}()
+		ctx.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery())
+	router.GET("/panic", func(*gin.Context) { panic("secret detail") })
+	router.GET("/late", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "partial")
+		panic("secret detail")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != ProblemType {
+		t.Errorf("GET /panic = %d %s, want a 500 problem", w.Code, w.Header().Get("Content-Type"))
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("GET /panic = %s, exposing the panic", w.Body)
+	}
+
+	// the status is already sent, the body is left as is
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/late", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("GET /late = %d %s, want the partial response", w.Code, w.Body)
+	}
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler to go through", v)
+		}
+	}()
+	router.GET("/abort", func(*gin.Context) { panic(http.ErrAbortHandler) })
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+}
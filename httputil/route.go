@@ -0,0 +1,56 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const unmatchedKey = "route_unmatched"
+
+// NoRoute marks the request as matching no route and responds with 404. It
+// must be the NoRoute handler of the engine for Route to tell unmatched
+// requests apart.
+func NoRoute(ctx *gin.Context) {
+	ctx.Set(unmatchedKey, true)
+	NewError(ctx, http.StatusNotFound, errors.New("page not found"))
+}
+
+// Route rebuilds the pattern of the route the request matched, e.g.
+// /api/book/:id, by putting the parameter names back in place of their
+// values. Unmatched requests give "unmatched".
+func Route(ctx *gin.Context) string {
+	if ctx.GetBool(unmatchedKey) {
+		return "unmatched"
+	}
+
+	segments := strings.Split(ctx.Request.URL.Path, "/")
+	for _, param := range ctx.Params {
+		if strings.HasPrefix(param.Value, "/") {
+			// catch all parameter, the rest of the path
+			n := strings.Count(param.Value, "/")
+			segments = append(segments[:len(segments)-n], "*"+param.Key)
+			continue
+		}
+		if i := lastSegment(segments, param.Value, false); i >= 0 {
+			segments[i] = ":" + param.Key
+		} else if i := lastSegment(segments, param.Value, true); i >= 0 {
+			// the parameter ends a segment, as in /sitemap-:file
+			segments[i] = strings.TrimSuffix(segments[i], param.Value) + ":" + param.Key
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// lastSegment returns the index of the last of segments equal to value, or
+// ending with it when suffix is set, -1 when none is.
+func lastSegment(segments []string, value string, suffix bool) int {
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] == value || suffix && value != "" && strings.HasSuffix(segments[i], value) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,37 @@
+package httputil
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.NoRoute(NoRoute)
+	var got string
+	// as the metrics, once served
+	router.Use(func(ctx *gin.Context) {
+		ctx.Next()
+		got = Route(ctx)
+	})
+	for _, pattern := range []string{"/api/v1/book/:id", "/tag/:id/feed.rss", "/sitemap-:file", "/api/docs/*any"} {
+		router.GET(pattern, func(*gin.Context) {})
+	}
+
+	for _, tt := range []struct{ path, want string }{
+		{"/api/v1/book/1", "/api/v1/book/:id"},
+		{"/tag/s/feed.rss", "/tag/:id/feed.rss"},
+		{"/sitemap-books-1.xml", "/sitemap-:file"},
+		{"/api/docs/swagger/index.html", "/api/docs/*any"},
+		{"/nowhere", "unmatched"},
+	} {
+		got = ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+		if got != tt.want {
+			t.Errorf("Route of %s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package images
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache keeps rendered images on disk, removing the least recently used
+// ones once they take more than MaxBytes.
+type Cache struct {
+	Dir      string
+	MaxBytes int64
+
+	once    sync.Once
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*entry
+}
+
+type entry struct {
+	size int64
+	used time.Time
+}
+
+// NewCache returns a cache in dir, which is created on the first write.
+func NewCache(dir string, maxBytes int64) *Cache {
+	return &Cache{Dir: dir, MaxBytes: maxBytes}
+}
+
+// load indexes the images left in the directory by a previous run.
+func (c *Cache) load() {
+	c.entries = map[string]*entry{}
+	files, err := ioutil.ReadDir(c.Dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) == ".tmp" {
+			continue
+		}
+		c.entries[f.Name()] = &entry{f.Size(), f.ModTime()}
+		c.size += f.Size()
+	}
+	c.evict()
+}
+
+// Get returns the image stored under key.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.once.Do(c.load)
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		e.used = time.Now()
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.Dir, key))
+	if err != nil {
+		c.remove(key)
+		return nil, false
+	}
+	// the modification time orders the entries again after a restart
+	os.Chtimes(filepath.Join(c.Dir, key), time.Now(), time.Now())
+	return data, true
+}
+
+// Put stores data under key, evicting older images to make room.
+func (c *Cache) Put(key string, data []byte) error {
+	c.once.Do(c.load)
+	if int64(len(data)) > c.MaxBytes {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.Dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.Dir, key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[key]; ok {
+		c.size -= old.size
+	}
+	c.entries[key] = &entry{int64(len(data)), time.Now()}
+	c.size += int64(len(data))
+	c.evict()
+	return nil
+}
+
+func (c *Cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.size -= e.size
+		delete(c.entries, key)
+	}
+}
+
+// evict removes the least recently used images until the cache fits, c.mu
+// must be held.
+func (c *Cache) evict() {
+	for c.size > c.MaxBytes && len(c.entries) > 0 {
+		var oldest string
+		for key, e := range c.entries {
+			if oldest == "" || e.used.Before(c.entries[oldest].used) {
+				oldest = key
+			}
+		}
+		os.Remove(filepath.Join(c.Dir, oldest))
+		c.size -= c.entries[oldest].size
+		delete(c.entries, oldest)
+	}
+}
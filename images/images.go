@@ -0,0 +1,318 @@
+// Package images renders book covers at the size the pages display them,
+// fetching the source image once and caching the result on disk.
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decodes GIF sources
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/kautsarady/adindopustaka/logging"
+	"github.com/kautsarady/adindopustaka/metrics"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decodes WebP sources
+	"golang.org/x/sync/singleflight"
+)
+
+// Output formats.
+const (
+	JPEG = "jpeg"
+	PNG  = "png"
+	WebP = "webp"
+)
+
+// maxPixels bounds the decoded size of a source image.
+const maxPixels = 40 << 20
+
+// Widths are the widths covers are rendered at, so that each source is
+// decoded and cached a few times rather than once per requested width.
+var Widths = []int{100, 200, 400, 600, 800, 1200}
+
+// Snap rounds width up to one of Widths, or down to the largest of them.
+// Zero, the width of the source, is kept.
+func Snap(width int) int {
+	if width == 0 {
+		return 0
+	}
+	for _, w := range Widths {
+		if w >= width {
+			return w
+		}
+	}
+	return Widths[len(Widths)-1]
+}
+
+// Options .
+type Options struct {
+	CacheDir       string
+	CacheMaxBytes  int64
+	FetchTimeout   time.Duration
+	MaxSourceBytes int64
+}
+
+// Proxy renders resized covers.
+type Proxy struct {
+	Cache *Cache
+	// Client fetches the http(s) sources, only from public addresses when
+	// made by New.
+	Client *http.Client
+
+	// Open reads the sources that are not http(s) URLs, such as uploaded
+	// covers. Those sources are refused when it is nil.
+	Open func(ctx context.Context, src string) (io.ReadCloser, error)
+
+	MaxSourceBytes int64
+
+	group singleflight.Group
+}
+
+// New .
+func New(opts Options) *Proxy {
+	return &Proxy{
+		Cache:          NewCache(opts.CacheDir, opts.CacheMaxBytes),
+		Client:         publicClient(opts.FetchTimeout),
+		MaxSourceBytes: opts.MaxSourceBytes,
+	}
+}
+
+// errPrivate refuses the sources on the network of the service.
+var errPrivate = errors.New("images: refusing to fetch from a private address")
+
+// publicClient returns a client connecting to public addresses only, so that
+// an image_url, or a redirect it answers with, cannot reach the network of
+// the service. Addresses are checked once resolved, as they are dialed.
+func publicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return errPrivate
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("images: refusing the redirect to %s", req.URL)
+			}
+			if len(via) >= 10 {
+				return errors.New("images: stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// Version identifies src, so that URLs carrying it can be cached forever.
+func Version(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:4])
+}
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Render returns src scaled down to width snapped to Widths, or at its own
+// width when width is 0 or larger, encoded as format. Concurrent renders of
+// the same image share one fetch, which outlives the request that started
+// it, and the fetched source is cached for the renders at other sizes.
+func (p *Proxy) Render(ctx context.Context, src string, width int, format string) ([]byte, error) {
+	width = Snap(width)
+	key := key(src, width, format)
+	if data, ok := p.Cache.Get(key); ok {
+		metrics.CacheLookup("images", true)
+		return data, nil
+	}
+	metrics.CacheLookup("images", false)
+
+	v, err, _ := p.group.Do(key, func() (interface{}, error) {
+		img, err := p.decode(context.WithoutCancel(ctx), src)
+		if err != nil {
+			return nil, err
+		}
+		data, err := encode(resize(img, width, format), format)
+		if err != nil {
+			return nil, err
+		}
+		// a full or read-only disk only costs the next request a render
+		if err := p.Cache.Put(key, data); err != nil {
+			logging.FromContext(ctx).Warn("cannot cache image", "source", src, "error", err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// Placeholder returns a blank cover of width, 200 when 0, encoded as format.
+func Placeholder(width int, format string) ([]byte, error) {
+	if width == 0 {
+		width = 200
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, width*3/2))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0xdd, 0xdd, 0xdd, 0xff}}, image.ZP, draw.Src)
+	return encode(img, format)
+}
+
+func key(src string, width int, format string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s", src, width, format)))
+	return hex.EncodeToString(sum[:]) + "." + format
+}
+
+// decode returns the image of src, refusing the images too large to decode.
+func (p *Proxy) decode(ctx context.Context, src string) (image.Image, error) {
+	data, err := p.source(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, errors.New("images: " + src + " has too many pixels")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// source returns the bytes of src, fetching them once for every size and
+// format rendered from it.
+func (p *Proxy) source(ctx context.Context, src string) ([]byte, error) {
+	key := sourceKey(src)
+	if data, ok := p.Cache.Get(key); ok {
+		metrics.CacheLookup("image_sources", true)
+		return data, nil
+	}
+	metrics.CacheLookup("image_sources", false)
+
+	v, err, _ := p.group.Do(key, func() (interface{}, error) {
+		data, err := p.fetch(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Cache.Put(key, data); err != nil {
+			logging.FromContext(ctx).Warn("cannot cache source image", "source", src, "error", err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+func sourceKey(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:]) + ".src"
+}
+
+func (p *Proxy) fetch(ctx context.Context, src string) ([]byte, error) {
+	var body io.ReadCloser
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		req, err := http.NewRequest(http.MethodGet, src, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := p.Client.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, fmt.Errorf("images: %s answered %s", src, res.Status)
+		}
+		body = res.Body
+	} else if p.Open != nil {
+		f, err := p.Open(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		body = f
+	} else {
+		return nil, fmt.Errorf("images: unsupported source %q", src)
+	}
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, p.MaxSourceBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > p.MaxSourceBytes {
+		return nil, fmt.Errorf("images: %s is larger than %d bytes", src, p.MaxSourceBytes)
+	}
+	return data, nil
+}
+
+// resize scales img down to width, keeping its aspect ratio. JPEG has no
+// transparency, so transparent pixels become white.
+func resize(img image.Image, width int, format string) image.Image {
+	b := img.Bounds()
+	if width == 0 || width > b.Dx() {
+		width = b.Dx()
+	}
+	height := b.Dy() * width / b.Dx()
+	if height == 0 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if format == JPEG {
+		draw.Draw(dst, dst.Bounds(), image.White, image.ZP, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case JPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80})
+	case PNG:
+		err = png.Encode(&buf, img)
+	case WebP:
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("images: unknown format %q", format)
+	}
+	return buf.Bytes(), err
+}
@@ -0,0 +1,131 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSnap(t *testing.T) {
+	for _, tt := range []struct{ width, want int }{
+		{0, 0},
+		{16, 100},
+		{100, 100},
+		{150, 200},
+		{1199, 1200},
+		{5000, 1200},
+	} {
+		if got := Snap(tt.width); got != tt.want {
+			t.Errorf("Snap(%d) = %d, want %d", tt.width, got, tt.want)
+		}
+	}
+}
+
+func cover(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 1200))
+	for x := 0; x < 800; x++ {
+		for y := 0; y < 1200; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0x80, 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRenderFetchesOnce(t *testing.T) {
+	source := cover(t)
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(source)
+	}))
+	defer srv.Close()
+
+	p := New(Options{CacheDir: t.TempDir(), CacheMaxBytes: 1 << 20, MaxSourceBytes: 10 << 20})
+	// the test server is on loopback
+	p.Client = srv.Client()
+
+	for _, width := range []int{150, 200, 400} {
+		data, err := p.Render(context.Background(), srv.URL+"/cover.png", width, JPEG)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := Snap(width); config.Width != want || config.Height != want*3/2 {
+			t.Errorf("Render(%d) = %dx%d, want %dx%d", width, config.Width, config.Height, want, want*3/2)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("source fetched %d times, want once", fetches)
+	}
+}
+
+func TestRenderRefusesLargeSources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(cover(t))
+	}))
+	defer srv.Close()
+
+	p := New(Options{CacheDir: t.TempDir(), CacheMaxBytes: 1 << 20, MaxSourceBytes: 1024})
+	p.Client = srv.Client()
+	if _, err := p.Render(context.Background(), srv.URL, 200, JPEG); err == nil {
+		t.Error("Render of a source over MaxSourceBytes succeeded")
+	}
+}
+
+func TestRenderRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("private address fetched")
+	}))
+	defer srv.Close()
+
+	p := New(Options{CacheDir: t.TempDir(), CacheMaxBytes: 1 << 20, FetchTimeout: time.Second, MaxSourceBytes: 1 << 20})
+	for _, src := range []string{srv.URL + "/cover.png", "file:///etc/passwd", "javascript:alert(1)"} {
+		if _, err := p.Render(context.Background(), src, 200, JPEG); err == nil {
+			t.Errorf("Render(%q) succeeded", src)
+		}
+	}
+	if _, err := p.Render(context.Background(), srv.URL, 200, JPEG); !errors.Is(err, errPrivate) {
+		t.Errorf("Render of a loopback source = %v, want %v", err, errPrivate)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for _, tt := range []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::1", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+	} {
+		if got := isPublic(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+const keysUsage = `usage: adindopustaka keys <command>
+
+commands:
+  create -name NAME -scopes catalog:read,catalog:write,admin
+  list
+  revoke ID`
+
+// keys manages the API keys stored in the database.
+func keys(ctx context.Context, dao *model.DAO, args []string) error {
+	if len(args) == 0 {
+		return errors.New(keysUsage)
+	}
+
+	if err := dao.CreateKeyTable(ctx); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("keys create", flag.ContinueOnError)
+		name := fs.String("name", "", "key owner")
+		scopes := fs.String("scopes", auth.ScopeRead, "comma separated scopes")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *name == "" {
+			return fmt.Errorf("keys create: -name is required")
+		}
+		for _, scope := range strings.Split(*scopes, ",") {
+			if scope != auth.ScopeRead && scope != auth.ScopeWrite && scope != auth.ScopeAdmin {
+				return fmt.Errorf("keys create: unknown scope %q", scope)
+			}
+		}
+
+		token, err := auth.GenerateKey()
+		if err != nil {
+			return err
+		}
+		key := &model.Key{
+			Name:      *name,
+			Hash:      auth.HashKey(token),
+			Scopes:    strings.Split(*scopes, ","),
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := dao.CreateKey(ctx, key); err != nil {
+			return err
+		}
+		fmt.Printf("created key %d for %s, store it now as it cannot be shown again:\n%s\n", key.ID, key.Name, token)
+
+	case "list":
+		keys, err := dao.GetKeys(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCREATED\tREVOKED")
+		for _, key := range keys {
+			revoked := "-"
+			if key.RevokedAt != nil {
+				revoked = key.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", key.ID, key.Name, strings.Join(key.Scopes, ","), key.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		return w.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(keysUsage)
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("keys revoke: invalid id %q", args[1])
+		}
+		if err := dao.RevokeKey(ctx, id); errors.Is(err, model.ErrNotFound) {
+			return fmt.Errorf("keys revoke: no active key %d", id)
+		} else if err != nil {
+			return err
+		}
+		fmt.Printf("revoked key %d\n", id)
+
+	default:
+		return errors.New(keysUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/kautsarady/adindopustaka/api"
+	"github.com/kautsarady/adindopustaka/config"
+	"github.com/kautsarady/adindopustaka/model"
+	"github.com/kautsarady/adindopustaka/ratelimit"
+)
+
+// rateLimits builds the rate limiters of the route groups, along with the
+// trusted proxies they and the logs take the client IP from.
+func rateLimits(cfg *config.Config, dao *model.DAO) (api.Options, error) {
+	var opts api.Options
+
+	// Validate already checked the proxies.
+	proxies, err := ratelimit.ParseCIDRs(strings.Join(cfg.Server.TrustedProxies, ","))
+	if err != nil {
+		return opts, err
+	}
+	opts.TrustedProxies = proxies
+
+	var store ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "mysql":
+		if store, err = ratelimit.NewSQLStore(dao.DB); err != nil {
+			return opts, err
+		}
+	}
+
+	limiter := func(name string, limit config.Limit) *ratelimit.Limiter {
+		if limit.Rate <= 0 {
+			return nil
+		}
+		return &ratelimit.Limiter{Name: name, Store: store, Rate: limit.Rate, Burst: limit.Burst, TrustedProxies: proxies}
+	}
+
+	opts.APILimit = limiter("API", cfg.RateLimit.API)
+	opts.AdminLimit = limiter("ADMIN", cfg.RateLimit.Admin)
+	opts.ImageLimit = limiter("IMAGES", cfg.RateLimit.Images)
+	return opts, nil
+}
@@ -0,0 +1,198 @@
+// Package linkcheck finds the book links that stopped working, scraped
+// product pages and cover images rotting over time.
+package linkcheck
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kautsarady/adindopustaka/metrics"
+	"github.com/kautsarady/adindopustaka/model"
+	"golang.org/x/sync/errgroup"
+)
+
+// pageSize is how many books are read at once.
+const pageSize = 100
+
+// Options .
+type Options struct {
+	// Concurrency bounds the links checked at once.
+	Concurrency int
+	// Timeout bounds the check of one link.
+	Timeout time.Duration
+}
+
+// Checker checks the image_url and gramed_url of every book, recording the
+// outcome of each link with DAO.SaveLinkCheck.
+type Checker struct {
+	DAO         *model.DAO
+	Client      *http.Client
+	Concurrency int
+}
+
+// New .
+func New(dao *model.DAO, opts Options) *Checker {
+	return &Checker{
+		DAO:         dao,
+		Client:      &http.Client{Timeout: opts.Timeout},
+		Concurrency: opts.Concurrency,
+	}
+}
+
+// Result counts the links checked by a run.
+type Result struct {
+	Checked int
+	// Broken counts the broken links by field.
+	Broken map[string]int
+}
+
+// Run checks every link once. Links that are not http(s) URLs, such as the
+// uploaded covers, are skipped, and checks that cannot be saved are logged.
+func (c *Checker) Run(ctx context.Context) (Result, error) {
+	result := Result{Broken: map[string]int{"image_url": 0, "gramed_url": 0}}
+	var mu sync.Mutex
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.Concurrency)
+	for offset := 0; ; offset += pageSize {
+		books, err := c.DAO.Get(ctx, "books", nil, nil, "id", pageSize, offset)
+		if err != nil {
+			g.Wait()
+			return result, err
+		}
+
+		for _, book := range model.ToBooks(books) {
+			for field, url := range map[string]string{"image_url": book.ImageURL, "gramed_url": book.GramedURL} {
+				if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+					continue
+				}
+				id, field, url := book.ID, field, url
+				g.Go(func() error {
+					check := c.check(ctx, id, field, url)
+					// one unsaved check is no reason to give up on the others
+					if err := c.DAO.SaveLinkCheck(ctx, &check); err != nil {
+						slog.Warn("cannot save link check", "book_id", id, "field", field, "error", err)
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					result.Checked++
+					if check.Broken {
+						result.Broken[field]++
+					}
+					return nil
+				})
+			}
+		}
+
+		if len(books) < pageSize {
+			break
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return result, err
+	}
+
+	metrics.LinkCheck(result.Broken)
+	return result, nil
+}
+
+// lockName is the advisory lock taken by the background runs of the
+// replicas sharing the database.
+const lockName = "adindopustaka.linkcheck"
+
+// Every runs the checker every interval until ctx is done, logging the
+// outcome of each run. Of the replicas sharing the database, only one runs
+// at a time, and a run is skipped when another replica checked the links less
+// than half an interval ago.
+func (c *Checker) Every(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		result, ran, err := c.runShared(ctx, interval)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("link check failed", "error", err)
+			}
+			continue
+		}
+		if !ran {
+			continue
+		}
+		slog.Info("link check done", "checked", result.Checked,
+			"broken_images", result.Broken["image_url"], "broken_pages", result.Broken["gramed_url"],
+			"duration_ms", float64(time.Since(start).Microseconds())/1000)
+	}
+}
+
+// runShared is Run under the lock shared by the replicas, reporting whether
+// the links were checked.
+func (c *Checker) runShared(ctx context.Context, interval time.Duration) (Result, bool, error) {
+	ok, unlock, err := c.DAO.TryLock(ctx, lockName)
+	if err != nil {
+		return Result{}, false, err
+	}
+	if !ok {
+		slog.Debug("link check running on another replica")
+		return Result{}, false, nil
+	}
+	defer unlock()
+
+	last, err := c.DAO.LastLinkCheck(ctx)
+	if err != nil {
+		return Result{}, false, err
+	}
+	if time.Since(last) < interval/2 {
+		slog.Debug("link check run by another replica", "checked_at", last)
+		return Result{}, false, nil
+	}
+	result, err := c.Run(ctx)
+	return result, true, err
+}
+
+// check requests url with HEAD, falling back to GET for the servers that do
+// not support HEAD. Links answering with an error status or not answering
+// at all are broken.
+func (c *Checker) check(ctx context.Context, bookID int, field, url string) model.LinkCheck {
+	check := model.LinkCheck{BookID: bookID, Field: field, URL: url}
+
+	status, err := c.status(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.status(ctx, http.MethodGet, url)
+	}
+
+	check.Status = status
+	check.CheckedAt = time.Now().UTC()
+	switch {
+	case err != nil:
+		check.Error = err.Error()
+		check.Broken = true
+	case status >= http.StatusBadRequest:
+		check.Error = http.StatusText(status)
+		check.Broken = true
+	}
+	return check
+}
+
+func (c *Checker) status(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	res, err := c.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
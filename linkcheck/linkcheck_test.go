@@ -0,0 +1,158 @@
+package linkcheck
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kautsarady/adindopustaka/model"
+	"modernc.org/sqlite"
+)
+
+// held stands in for the advisory locks of MySQL.
+var held sync.Map
+
+func init() {
+	sqlite.MustRegisterScalarFunction("GET_LOCK", 2, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		if _, taken := held.LoadOrStore(args[0], true); taken {
+			return int64(0), nil
+		}
+		return int64(1), nil
+	})
+	sqlite.MustRegisterScalarFunction("RELEASE_LOCK", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		held.Delete(args[0])
+		return int64(1), nil
+	})
+}
+
+func TestRunKeepsCheckingWhenSavesFail(t *testing.T) {
+	links := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer links.Close()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	// without a link_checks table, every save fails
+	for _, q := range []string{
+		"CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, image_url TEXT, gramed_url TEXT, description TEXT)",
+		"INSERT INTO books VALUES (1, 'One', '" + links.URL + "/gone', '" + links.URL + "/1', '')",
+		"INSERT INTO books VALUES (2, 'Two', '/covers/2.png', '" + links.URL + "/2', '')",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(q, err)
+		}
+	}
+
+	c := New(&model.DAO{DB: db}, Options{Concurrency: 2, Timeout: time.Second})
+	result, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checked != 3 || result.Broken["image_url"] != 1 || result.Broken["gramed_url"] != 0 {
+		t.Errorf("Run() = %+v, want 3 checked and 1 broken image", result)
+	}
+}
+
+func TestCheckFallsBackToGet(t *testing.T) {
+	var methods []string
+	links := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/no-head":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.Method == http.MethodHead && r.URL.Path == "/no-head-gone":
+			w.WriteHeader(http.StatusNotImplemented)
+		case r.URL.Path == "/no-head-gone":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer links.Close()
+
+	c := New(nil, Options{Timeout: time.Second})
+	for _, tt := range []struct {
+		path    string
+		methods []string
+		status  int
+		broken  bool
+	}{
+		{"/ok", []string{"HEAD"}, http.StatusOK, false},
+		{"/no-head", []string{"HEAD", "GET"}, http.StatusOK, false},
+		{"/no-head-gone", []string{"HEAD", "GET"}, http.StatusNotFound, true},
+	} {
+		methods = nil
+		check := c.check(context.Background(), 1, "gramed_url", links.URL+tt.path)
+		if check.Status != tt.status || check.Broken != tt.broken {
+			t.Errorf("check(%s) = %d broken %v, want %d broken %v", tt.path, check.Status, check.Broken, tt.status, tt.broken)
+		}
+		if fmt.Sprint(methods) != fmt.Sprint(tt.methods) {
+			t.Errorf("check(%s) requested %v, want %v", tt.path, methods, tt.methods)
+		}
+	}
+}
+
+func TestRunSharedSkipsRecentChecks(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "links.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	dao := &model.DAO{DB: db}
+	ctx := context.Background()
+	if _, err := db.Exec("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, image_url TEXT, gramed_url TEXT, description TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := dao.CreateLinkTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c := New(dao, Options{Concurrency: 1, Timeout: time.Second})
+
+	for _, tt := range []struct {
+		name    string
+		checked time.Duration
+		ran     bool
+	}{
+		{"never checked", 0, true},
+		{"checked by another replica", 10 * time.Minute, false},
+		{"checked long ago", 40 * time.Minute, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.Exec("DELETE FROM link_checks"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.checked > 0 {
+				check := model.LinkCheck{BookID: 1, Field: "image_url", URL: "http://example.com", Status: 200, CheckedAt: time.Now().UTC().Add(-tt.checked)}
+				if err := dao.SaveLinkCheck(ctx, &check); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, ran, err := c.runShared(ctx, time.Hour)
+			if err != nil || ran != tt.ran {
+				t.Errorf("runShared = %v, %v, want %v", ran, err, tt.ran)
+			}
+		})
+	}
+
+	// another replica holds the lock
+	ok, unlock, err := dao.TryLock(ctx, lockName)
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	defer unlock()
+	if _, ran, err := c.runShared(ctx, time.Hour); err != nil || ran {
+		t.Errorf("runShared while locked = %v, %v, want a skip", ran, err)
+	}
+}
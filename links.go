@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kautsarady/adindopustaka/config"
+	"github.com/kautsarady/adindopustaka/linkcheck"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+// linkChecker builds the dead link checker.
+func linkChecker(cfg *config.Config, dao *model.DAO) *linkcheck.Checker {
+	return linkcheck.New(dao, linkcheck.Options{
+		Concurrency: cfg.LinkCheck.Concurrency,
+		Timeout:     cfg.LinkCheck.Timeout,
+	})
+}
+
+// checkLinks checks every book link once.
+func checkLinks(ctx context.Context, checker *linkcheck.Checker) error {
+	if err := checker.DAO.CreateLinkTable(ctx); err != nil {
+		return err
+	}
+
+	result, err := checker.Run(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("checked %d links: %d broken images, %d broken pages\n",
+		result.Checked, result.Broken["image_url"], result.Broken["gramed_url"])
+	return nil
+}
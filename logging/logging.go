@@ -0,0 +1,124 @@
+// Package logging provides the structured logger of the service, scoped to
+// the request being served.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup makes a logger writing to w at level, in json or text format, the
+// default one, which the log package writes through as well.
+func Setup(w io.Writer, level, format string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("logging: %v", err)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("logging: unknown format %q", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the request ctx belongs to, or the
+// default logger, along with the trace of ctx if any.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		logger = slog.Default()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		logger = logger.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	return logger
+}
+
+// RequestIDHeader carries the id of a request, taken from the caller when
+// it sent one and generated otherwise.
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Middleware assigns every request an id, echoed in the response, scopes the
+// logger of the request to it and writes an access log line once served,
+// with the client address clientIP finds, or the remote one when nil.
+func Middleware(clientIP func(*http.Request) string) gin.HandlerFunc {
+	if clientIP == nil {
+		clientIP = remoteIP
+	}
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		ctx.Header(RequestIDHeader, id)
+		logger := slog.Default().With("request_id", id)
+		ctx.Request = ctx.Request.WithContext(NewContext(ctx.Request.Context(), logger))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		FromContext(ctx.Request.Context()).LogAttrs(ctx.Request.Context(), level, "request",
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ctx.Writer.Size()),
+			slog.Float64("duration_ms", float64(time.Since(start))/float64(time.Millisecond)),
+			slog.String("client_ip", clientIP(ctx.Request)),
+			slog.String("user_agent", ctx.Request.UserAgent()),
+		)
+	}
+}
+
+// remoteIP returns the address of the peer of r, which no header can change.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// record serves a request through Middleware with clientIP, returning the
+// response and the access log line.
+func record(t *testing.T, clientIP func(*http.Request) string, r *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(old) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(clientIP))
+	router.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log %q: %v", buf.String(), err)
+	}
+	return w, line
+}
+
+func TestMiddlewareClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.9:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	// a client cannot pass for another by default
+	if _, line := record(t, nil, r); line["client_ip"] != "203.0.113.9" {
+		t.Errorf("client_ip = %v, want the remote address", line["client_ip"])
+	}
+
+	trusting := func(r *http.Request) string { return r.Header.Get("X-Forwarded-For") }
+	if _, line := record(t, trusting, r); line["client_ip"] != "198.51.100.1" {
+		t.Errorf("client_ip = %v, want the one of clientIP", line["client_ip"])
+	}
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	for _, tt := range []struct {
+		name, sent string
+		kept       bool
+	}{
+		{"none", "", false},
+		{"valid", "abc-123", true},
+		{"invalid", "a b\nc", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.sent != "" {
+				r.Header.Set(RequestIDHeader, tt.sent)
+			}
+			w, line := record(t, nil, r)
+			id := w.Header().Get(RequestIDHeader)
+			if tt.kept && id != tt.sent || !tt.kept && !validRequestID.MatchString(id) {
+				t.Errorf("%s = %q", RequestIDHeader, id)
+			}
+			if line["request_id"] != id || line["status"] != float64(http.StatusNoContent) {
+				t.Errorf("log = %v, want request_id %s and status 204", line, id)
+			}
+		})
+	}
+}
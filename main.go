@@ -1,28 +1,215 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"crypto/rand"
+	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/kautsarady/adindopustaka/api"
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/config"
+	"github.com/kautsarady/adindopustaka/health"
+	"github.com/kautsarady/adindopustaka/images"
+	"github.com/kautsarady/adindopustaka/logging"
+	"github.com/kautsarady/adindopustaka/metrics"
 	"github.com/kautsarady/adindopustaka/model"
+	"github.com/kautsarady/adindopustaka/rpc"
+	"github.com/kautsarady/adindopustaka/tracing"
+	"google.golang.org/grpc"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
 func main() {
 
-	cs := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s",
-		os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_DBNAME"))
+	cfg, args, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	dao, err := model.Make(cs)
+	if len(args) > 0 && args[0] == "config" {
+		if err := printConfig(cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.Log.Level, cfg.Log.Format); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// a second signal kills the process right away
+		<-ctx.Done()
+		stop()
+	}()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	controller := api.Make(dao)
+	dao, err := model.Make(ctx, cfg.DB.DSN(), model.Options{
+		MaxOpenConns:    cfg.DB.MaxOpenConns,
+		MaxIdleConns:    cfg.DB.MaxIdleConns,
+		ConnMaxLifetime: cfg.DB.ConnMaxLifetime,
+		ConnectTimeout:  cfg.DB.ConnectTimeout,
+		ReadRetries:     cfg.DB.ReadRetries,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	addr := ":" + os.Getenv("PORT")
-	log.Fatal(controller.Router.Run(addr))
+	if err := metrics.RegisterDB(dao.DB, cfg.DB.Name); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "keys":
+			if err := keys(ctx, dao, args[1:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "users":
+			if err := users(ctx, dao, args[1:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "check-links":
+			if err := checkLinks(ctx, linkChecker(cfg, dao)); err != nil {
+				log.Fatal(err)
+			}
+			return
+		default:
+			log.Fatalf("unknown command %q", args[0])
+		}
+	}
+
+	secret := []byte(cfg.Auth.SessionSecret)
+	if len(secret) == 0 {
+		log.Println("auth.session_secret is not set, admin sessions will not survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	authn := &auth.Authenticator{DAO: dao, Sessions: &auth.SessionStore{Secret: secret, MaxAge: cfg.Auth.SessionMaxAge, Secure: cfg.Auth.SessionSecure}}
+	if cfg.Auth.JWTSecret != "" {
+		authn.HMACSecret = []byte(cfg.Auth.JWTSecret)
+	}
+	if path := cfg.Auth.JWTPublicKeyFile; path != "" {
+		if authn.RSAPublicKey, err = auth.LoadRSAPublicKey(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	opts, err := rateLimits(cfg, dao)
+	if err != nil {
+		log.Fatal(err)
+	}
+	corsPolicies(cfg, &opts)
+	opts.Timeouts = api.Timeouts{
+		Get:     cfg.QueryTimeout.Get,
+		List:    cfg.QueryTimeout.List,
+		GraphQL: cfg.QueryTimeout.GraphQL,
+		Admin:   cfg.QueryTimeout.Admin,
+	}
+
+	opts.Health = &health.Checker{
+		Checks: []health.Check{
+			{Name: "database", Run: dao.Ping},
+			{Name: "migrations", Run: dao.CheckSchema},
+		},
+		Timeout: cfg.Server.ReadinessTimeout,
+	}
+
+	opts.Images = images.New(images.Options{
+		CacheDir:       cfg.Images.CacheDir,
+		CacheMaxBytes:  int64(cfg.Images.CacheMaxMB) << 20,
+		FetchTimeout:   cfg.Images.FetchTimeout,
+		MaxSourceBytes: int64(cfg.Images.MaxSourceMB) << 20,
+	})
+	opts.PublicURL = cfg.Server.PublicURL
+	opts.AllowedHosts = cfg.Server.AllowedHosts
+	if opts.PublicURL == "" && len(opts.AllowedHosts) == 0 {
+		log.Println("neither server.public_url nor server.allowed_hosts is set, the links of the pages follow the Host header and are not cached publicly")
+	}
+	if err := coverStorage(cfg, &opts); err != nil {
+		log.Fatal(err)
+	}
+	controller := api.Make(dao, authn, opts)
+
+	// the writes keep the updated_at of the books, which the sitemaps read
+	if err := dao.MigrateBooks(ctx); err != nil {
+		log.Fatal(err)
+	}
+	// the pages read the outcome of the link checks
+	if err := dao.CreateLinkTable(ctx); err != nil {
+		log.Fatal(err)
+	}
+	// the API authenticates against the keys and the console against the
+	// users, before any is created
+	if err := dao.CreateKeyTable(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := dao.CreateUserTable(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := dao.CreateSessionTable(ctx); err != nil {
+		log.Fatal(err)
+	}
+	// the console adds authors, categories and tags
+	if err := dao.CreateItemLockTable(ctx); err != nil {
+		log.Fatal(err)
+	}
+	// the checks stop along with the servers, before the database closes
+	checks, stopChecks := context.WithCancel(ctx)
+	var checking sync.WaitGroup
+	if cfg.LinkCheck.Interval > 0 {
+		checking.Add(1)
+		go func() {
+			defer checking.Done()
+			linkChecker(cfg, dao).Every(checks, cfg.LinkCheck.Interval)
+		}()
+	}
+
+	var grpcSrv *grpc.Server
+	if cfg.Server.GRPCPort != 0 {
+		grpcSrv = rpc.NewServer(dao)
+	}
+
+	err = serve(ctx, cfg, controller.Router, grpcSrv, opts.Health)
+	stopChecks()
+	checking.Wait()
+	if err := dao.DB.Close(); err != nil {
+		log.Println(err)
+	}
+	flush, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flush); err != nil {
+		log.Println(err)
+	}
+	cancel()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("shut down")
 }
@@ -0,0 +1,133 @@
+// Package metrics exposes the Prometheus metrics of the service.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "adindopustaka"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	queries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "queries_total",
+		Help:      "DAO queries by entity, operation and outcome.",
+	}, []string{"entity", "operation", "status"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "DAO query latency by entity and operation.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"entity", "operation"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "Cache lookups by cache and result, hit or miss; the hit ratio is hits over all lookups.",
+	}, []string{"cache", "result"})
+
+	brokenLinks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "linkcheck",
+		Name:      "broken_links",
+		Help:      "Book links found broken by the last link check, by field.",
+	}, []string{"field"})
+
+	linkCheckTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "linkcheck",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix time the last complete link check finished.",
+	})
+)
+
+// Registry holds the metrics of the service and of the Go runtime.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		httpRequests, httpDuration, queries, queryDuration, cacheLookups, brokenLinks, linkCheckTime,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records the count and latency of every request, labelled with
+// the route pattern rather than the path so that ids do not blow up the
+// number of series.
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := httputil.Route(ctx)
+		httpRequests.WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(ctx.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveQuery records a DAO query. sql.ErrNoRows counts as a success.
+func ObserveQuery(entity, operation string, d time.Duration, err error) {
+	status := "ok"
+	if err != nil && err != sql.ErrNoRows {
+		status = "error"
+	}
+	queries.WithLabelValues(entity, operation, status).Inc()
+	queryDuration.WithLabelValues(entity, operation).Observe(d.Seconds())
+}
+
+// CacheLookup records a lookup in the named cache.
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// LinkCheck records a complete link check, broken counting the broken links
+// by field.
+func LinkCheck(broken map[string]int) {
+	for field, n := range broken {
+		brokenLinks.WithLabelValues(field).Set(float64(n))
+	}
+	linkCheckTime.SetToCurrentTime()
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.NoRoute(httputil.NoRoute)
+	router.GET("/book/:id", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, path := range []string{"/book/1", "/book/2", "/nowhere/1", "/nowhere/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// the ids share a series
+	if n := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/book/:id", "200")); n != 2 {
+		t.Errorf("requests of /book/:id = %v, want 2", n)
+	}
+	if n := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")); n != 2 {
+		t.Errorf("unmatched requests = %v, want 2", n)
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	ObserveQuery("books", "get", time.Millisecond, nil)
+	ObserveQuery("books", "get", time.Millisecond, sql.ErrNoRows)
+	ObserveQuery("books", "get", time.Millisecond, errors.New("bad connection"))
+
+	if n := testutil.ToFloat64(queries.WithLabelValues("books", "get", "ok")); n != 2 {
+		t.Errorf("ok queries = %v, want 2, sql.ErrNoRows being a success", n)
+	}
+	if n := testutil.ToFloat64(queries.WithLabelValues("books", "get", "error")); n != 1 {
+		t.Errorf("failed queries = %v, want 1", n)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	CacheLookup("images", true)
+	LinkCheck(map[string]int{"image_url": 3})
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range []string{
+		`adindopustaka_cache_lookups_total{cache="images",result="hit"} 1`,
+		`adindopustaka_linkcheck_broken_links{field="image_url"} 3`,
+		"go_goroutines ",
+	} {
+		if !strings.Contains(w.Body.String(), line) {
+			t.Errorf("metrics lack %s", line)
+		}
+	}
+}
@@ -1,28 +1,52 @@
 package model
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 // DAO .
-type DAO struct{ DB *sql.DB }
+type DAO struct {
+	DB *sql.DB
 
-// Make .
-func Make(connStr string) (*DAO, error) {
+	// ReadRetries is how many times a read failing with a transient error is
+	// retried.
+	ReadRetries int
+}
+
+// Make opens the database and waits for it to answer, for as long as ctx is
+// not done.
+func Make(ctx context.Context, connStr string, opts Options) (*DAO, error) {
 	db, err := sql.Open("mysql", connStr)
 	if err != nil {
 		return nil, err
 	}
-	return &DAO{db}, nil
+
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+
+	if err := connect(ctx, db, opts.ConnectTimeout); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DAO{db, opts.ReadRetries}, nil
 }
 
-// Get .
-func (d *DAO) Get(entity string, filter []string, limit, offset int) ([]interface{}, error) {
+// Get returns a page of the rows of entity matching filter, ordered by sort,
+// one of the Sorts of entity, or unordered when sort is empty.
+func (d *DAO) Get(ctx context.Context, entity string, fields Fields, filter []string, sort string, limit, offset int) ([]interface{}, error) {
+	order, err := orderValue(entity, sort)
+	if err != nil {
+		return nil, err
+	}
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT ? OFFSET ?", entity, whereValue(filter))
-	rows, err := d.DB.Query(query, limit, offset)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s%s LIMIT ? OFFSET ?",
+		selectValue(fields.Columns(entity)), entity, whereValue(filter), order)
+	rows, err := d.query(ctx, entity, "get", query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -50,10 +74,10 @@ func (d *DAO) Get(entity string, filter []string, limit, offset int) ([]interfac
 }
 
 // GetDistinctItems .
-func (d *DAO) GetDistinctItems(entity string, limit, offset int) ([]interface{}, error) {
+func (d *DAO) GetDistinctItems(ctx context.Context, entity string, limit, offset int) ([]interface{}, error) {
 
 	query := fmt.Sprintf("SELECT * FROM %s GROUP BY id LIMIT ? OFFSET ?", entity)
-	rows, err := d.DB.Query(query, limit, offset)
+	rows, err := d.query(ctx, entity, "list", query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -67,53 +91,221 @@ func (d *DAO) GetDistinctItems(entity string, limit, offset int) ([]interface{},
 	return items, nil
 }
 
-// GetBookByID .
-func (d *DAO) GetBookByID(id string) (*Book, error) {
+// GetBookByID returns the book with the given id, or ErrNotFound, along with
+// the relations fields selects.
+func (d *DAO) GetBookByID(ctx context.Context, id string, fields Fields) (*Book, error) {
+	n, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
 
-	books, err := d.Get("books", []string{fmt.Sprintf("id = %s", id)}, 1, 0)
+	books, err := d.Get(ctx, "books", fields, []string{fmt.Sprintf("id = %d", n)}, "", 1, 0)
 	if err != nil {
 		return nil, err
 	}
+	if len(books) == 0 {
+		return nil, ErrNotFound
+	}
+
+	relate := []Book{books[0].(Book)}
+	if err := d.LoadRelations(ctx, relate, fields.Relations()...); err != nil {
+		return nil, err
+	}
+
+	return &relate[0], nil
+}
 
-	authors, err := d.Get("authors", []string{fmt.Sprintf("book_id = %s", id)}, 99, 0)
+// LoadRelations attaches the given relations to books, issuing one query per
+// relation regardless of the number of books.
+func (d *DAO) LoadRelations(ctx context.Context, books []Book, relations ...string) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(books))
+	var bookIDs []string
+	for i, book := range books {
+		index[book.ID] = i
+		bookIDs = append(bookIDs, strconv.Itoa(book.ID))
+	}
+
+	for _, relation := range relations {
+		if !IsRelation(relation) {
+			return unknownRelation(relation)
+		}
+
+		query := fmt.Sprintf("SELECT * FROM %s WHERE book_id IN(%s)", relation, strings.Join(bookIDs, ", "))
+		rows, err := d.query(ctx, relation, "load_relations", query)
+		if err != nil {
+			return err
+		}
+
+		var items []interface{}
+		err = handleItems(&items, rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range ToItems(items) {
+			i, ok := index[item.BookID]
+			if !ok {
+				continue
+			}
+			related := RelationOf(&books[i], relation)
+			*related = append(*related, item)
+		}
+	}
+
+	return nil
+}
+
+// GetItemByID returns the item of entity with the given id along with a page
+// of its books, or ErrNotFound.
+func (d *DAO) GetItemByID(ctx context.Context, entity string, id string, fields Fields, limit, offset int) (*Item, error) {
+	n, err := parseID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	categories, err := d.Get("categories", []string{fmt.Sprintf("book_id = %s", id)}, 99, 0)
+	result, err := d.Get(ctx, entity, nil, []string{fmt.Sprintf("id = %d", n)}, "", 99, 0)
 	if err != nil {
 		return nil, err
 	}
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
 
-	tags, err := d.Get("tags", []string{fmt.Sprintf("book_id = %s", id)}, 99, 0)
+	item, bookIDs := ItemAndIDs(result)
+
+	books, err := d.Get(ctx, "books", fields.Sub("books"), []string{fmt.Sprintf("id IN(%s)", strings.Join(bookIDs, ", "))}, "", limit, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	book := books[0].(Book)
-	book.Authors = ToItems(authors)
-	book.Categories = ToItems(categories)
-	book.Tags = ToItems(tags)
+	item.Books = ToBooks(books)
 
-	return &book, nil
+	return &item, nil
 }
 
-// GetItemByID .
-func (d *DAO) GetItemByID(entity string, id string, limit, offset int) (*Item, error) {
+// GetBooksByItems returns a page of the books of every item of entity with the
+// given ids, keyed by item id, using two queries regardless of the number of
+// items.
+func (d *DAO) GetBooksByItems(ctx context.Context, entity string, ids []int, limit, offset int) (map[int][]Book, error) {
+	if !IsRelation(entity) {
+		return nil, unknownRelation(entity)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
 
-	result, err := d.Get(entity, []string{fmt.Sprintf("id = %s", id)}, 99, 0)
-	if err != nil || len(result) == 0 {
+	// each item is paged on its own, which a single LIMIT cannot do
+	pages := make([]string, len(ids))
+	var args []interface{}
+	for i, id := range ids {
+		pages[i] = fmt.Sprintf("SELECT * FROM (SELECT * FROM %s WHERE id = %d ORDER BY book_id LIMIT ? OFFSET ?) page%d", entity, id, i)
+		args = append(args, limit, offset)
+	}
+	rows, err := d.query(ctx, entity, "books_by_items", strings.Join(pages, " UNION ALL "), args...)
+	if err != nil {
 		return nil, err
 	}
 
-	item, bookIDs := ItemAndIDs(result)
+	var result []interface{}
+	err = handleItems(&result, rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	items := ToItems(result)
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[int]bool)
+	var bookIDs []string
+	for _, item := range items {
+		if !seen[item.BookID] {
+			seen[item.BookID] = true
+			bookIDs = append(bookIDs, strconv.Itoa(item.BookID))
+		}
+	}
 
-	books, err := d.Get("books", []string{fmt.Sprintf("id IN(%s)", strings.Join(bookIDs, ", "))}, limit, offset)
+	books, err := d.Get(ctx, "books", nil, []string{fmt.Sprintf("id IN(%s)", strings.Join(bookIDs, ", "))}, "", len(bookIDs), 0)
 	if err != nil {
 		return nil, err
 	}
 
-	item.Books = ToBooks(books)
+	byID := make(map[int]Book, len(books))
+	for _, book := range ToBooks(books) {
+		byID[book.ID] = book
+	}
 
-	return &item, nil
+	itemBooks := make(map[int][]Book)
+	for _, item := range items {
+		if book, ok := byID[item.BookID]; ok {
+			itemBooks[item.ID] = append(itemBooks[item.ID], book)
+		}
+	}
+
+	return itemBooks, nil
+}
+
+// Search returns the books whose title or description contains term.
+func (d *DAO) Search(ctx context.Context, term string, limit, offset int) ([]interface{}, error) {
+
+	pattern := "%" + likeEscaper.Replace(term) + "%"
+	rows, err := d.query(ctx, "books", "search", "SELECT * FROM books WHERE title LIKE ? OR description LIKE ? LIMIT ? OFFSET ?",
+		pattern, pattern, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var books []interface{}
+	if err := handleBooks(&books, rows); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// GetNewBooks returns the limit books added last, with their authors. When
+// entity is not empty, only the books of its item with the given id are
+// returned. The books that came with the data, which were never added, are
+// left out.
+func (d *DAO) GetNewBooks(ctx context.Context, entity string, id, limit int) ([]Book, error) {
+	where := "created_at IS NOT NULL"
+	if entity != "" {
+		if !IsRelation(entity) {
+			return nil, unknownRelation(entity)
+		}
+		where += fmt.Sprintf(" AND id IN(SELECT book_id FROM %s WHERE id = %d)", entity, id)
+	}
+
+	rows, err := d.query(ctx, "books", "new", fmt.Sprintf("SELECT * FROM books WHERE %s ORDER BY created_at DESC, id DESC LIMIT ?", where), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []interface{}
+	err = handleBooks(&result, rows)
+	if err == nil {
+		err = rows.Err()
+	}
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	books := ToBooks(result)
+	if err := d.LoadRelations(ctx, books, "authors"); err != nil {
+		return nil, err
+	}
+	return books, nil
 }
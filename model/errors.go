@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Domain errors returned by the DAO, to be told apart with errors.Is.
+var (
+	// ErrNotFound is returned when the requested row does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write collides with an existing row.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is matched by every *ValidationError.
+	ErrValidation = errors.New("validation failed")
+)
+
+// FieldError describes why the value of a field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when input is rejected before reaching the
+// database.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Invalid returns a validation error for a single field.
+func Invalid(field, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{[]FieldError{{field, fmt.Sprintf(format, args...)}}}
+}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return strings.Join(msgs, ", ")
+}
+
+// Is makes errors.Is(err, ErrValidation) hold.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// conflict turns a duplicate key error into ErrConflict.
+func conflict(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 { // duplicate entry
+		return fmt.Errorf("%w: %s", ErrConflict, mysqlErr.Message)
+	}
+	return err
+}
+
+// parseID rejects ids that are not positive integers, as they are
+// interpolated into queries.
+func parseID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, Invalid("id", "must be a positive integer")
+	}
+	return n, nil
+}
+
+// unknownRelation .
+func unknownRelation(entity string) error {
+	return Invalid("entity", "unknown relation %q", entity)
+}
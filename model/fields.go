@@ -0,0 +1,119 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fields is a sparse fieldset parsed from a comma separated list such as
+// "id,title,authors.name". A nil Fields (or a nil sub field) selects
+// everything below it.
+type Fields map[string]Fields
+
+// ParseFields .
+func ParseFields(list string) (Fields, error) {
+	if list == "" {
+		return nil, nil
+	}
+	fields := Fields{}
+	for _, path := range strings.Split(list, ",") {
+		path = strings.TrimSpace(path)
+		parts := strings.Split(path, ".")
+		node := fields
+		for i, part := range parts {
+			if part == "" {
+				return nil, fmt.Errorf("invalid field %q", path)
+			}
+			child, seen := node[part]
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if seen && child == nil {
+				break
+			}
+			if !seen {
+				child = Fields{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return fields, nil
+}
+
+// Validate checks every field against the JSON shape of entity.
+func (f Fields) Validate(entity string) error {
+	for name, sub := range f {
+		nested, ok := fieldOf(entity, name)
+		if !ok {
+			return fmt.Errorf("unknown field %q", name)
+		}
+		if sub == nil {
+			continue
+		}
+		if nested == "" {
+			return fmt.Errorf("field %q has no sub fields", name)
+		}
+		if err := sub.Validate(nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sub returns the fieldset nested under name.
+func (f Fields) Sub(name string) Fields {
+	if f == nil {
+		return nil
+	}
+	return f[name]
+}
+
+// Relations returns the relations a books fieldset selects, every one of them
+// when nil.
+func (f Fields) Relations() []string {
+	if f == nil {
+		return Relations
+	}
+	var relations []string
+	for _, relation := range Relations {
+		if _, ok := f[relation]; ok {
+			relations = append(relations, relation)
+		}
+	}
+	return relations
+}
+
+// Columns returns the SELECT column list for entity. Items are always read
+// whole since every one of their columns is needed to group them.
+func (f Fields) Columns(entity string) []string {
+	if f == nil || entity != "books" {
+		return nil
+	}
+	columns := []string{"id"}
+	for _, column := range bookColumns[1:] {
+		if _, ok := f[column]; ok {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+func fieldOf(entity, name string) (nested string, ok bool) {
+	if entity == "books" {
+		for _, column := range bookColumns {
+			if column == name {
+				return "", true
+			}
+		}
+		return name, IsRelation(name)
+	}
+	switch name {
+	case "id", "name":
+		return "", true
+	case "books":
+		return "books", true
+	}
+	return "", false
+}
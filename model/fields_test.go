@@ -0,0 +1,64 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestFieldsRelations(t *testing.T) {
+	for _, tt := range []struct {
+		list string
+		want []string
+	}{
+		{"", Relations},
+		{"id,title", nil},
+		{"title,tags,authors.name", []string{"authors", "tags"}},
+	} {
+		fields, err := ParseFields(tt.list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fields.Relations(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Relations of %q = %v, want %v", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestGetBookByIDRelations(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	for _, q := range []string{
+		"CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, image_url TEXT, gramed_url TEXT, description TEXT, updated_at DATETIME, created_at DATETIME)",
+		"CREATE TABLE authors (id INTEGER, book_id INTEGER, name TEXT)",
+		"INSERT INTO books (id, title, image_url, gramed_url, description) VALUES (1, 'One', '', '', '')",
+		"INSERT INTO authors VALUES (7, 1, 'ann')",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(q, err)
+		}
+	}
+	d := &DAO{DB: db}
+
+	// without categories and tags tables, loading them would fail
+	fields, _ := ParseFields("title,authors.name")
+	book, err := d.GetBookByID(context.Background(), "1", fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Authors) != 1 || book.Authors[0].Name != "Ann" {
+		t.Errorf("authors = %+v, want Ann", book.Authors)
+	}
+
+	fields, _ = ParseFields("title")
+	if book, err = d.GetBookByID(context.Background(), "1", fields); err != nil || book.Authors != nil {
+		t.Errorf("GetBookByID(title) = %+v, %v, want no authors", book, err)
+	}
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"time"
+
+	"github.com/kautsarady/adindopustaka/logging"
+	"github.com/kautsarady/adindopustaka/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/kautsarady/adindopustaka/model")
+
+// execer runs statements, in or out of a transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// op is a DAO operation being measured and traced.
+type op struct {
+	ctx          context.Context
+	entity, name string
+	start        time.Time
+	span         trace.Span
+}
+
+// begin starts operation on entity. The query text is reduced to its shape
+// so that spans do not carry ids nor search terms.
+func begin(ctx context.Context, entity, operation, query string) (context.Context, *op) {
+	ctx, span := tracer.Start(ctx, operation+" "+entity,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMySQL,
+			semconv.DBCollectionName(entity),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(shape(query)),
+		))
+	if m := where.FindStringSubmatch(query); m != nil {
+		span.SetAttributes(attribute.String("db.query.filter", shape(m[1])))
+	}
+	return ctx, &op{ctx, entity, operation, time.Now(), span}
+}
+
+// end records the outcome of the operation, which read or changed rows rows
+// unless negative. sql.ErrNoRows counts as a success.
+func (o *op) end(rows int, err error) {
+	if rows >= 0 {
+		o.span.SetAttributes(attribute.Int("db.rows", rows))
+	}
+	if err != nil && err != sql.ErrNoRows {
+		o.span.RecordError(err)
+		o.span.SetStatus(codes.Error, err.Error())
+		logging.FromContext(o.ctx).Error("query failed",
+			"entity", o.entity, "operation", o.name, "duration_ms", float64(time.Since(o.start))/float64(time.Millisecond), "error", err)
+	}
+	o.span.End()
+	metrics.ObserveQuery(o.entity, o.name, time.Since(o.start), err)
+}
+
+var (
+	literals = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|\b\d+\b`)
+	lists    = regexp.MustCompile(`\(\?(?:, \?)*\)`)
+	where    = regexp.MustCompile(`(?i)\bWHERE (.+?)(?: GROUP BY| ORDER BY| LIMIT|$)`)
+)
+
+// shape replaces the literals of query with placeholders and collapses IN
+// lists, e.g. "id IN(1, 2, 3)" into "id IN(?)".
+func shape(query string) string {
+	return lists.ReplaceAllString(literals.ReplaceAllString(query, "?"), "(?)")
+}
+
+// exec runs a statement as operation on entity.
+func exec(ctx context.Context, db execer, entity, operation, query string, args ...interface{}) (sql.Result, error) {
+	ctx, o := begin(ctx, entity, operation, query)
+	res, err := db.ExecContext(ctx, query, args...)
+	rows := -1
+	if err == nil {
+		if n, err := res.RowsAffected(); err == nil {
+			rows = int(n)
+		}
+	}
+	o.end(rows, err)
+	return res, conflict(err)
+}
+
+// scanRow runs a single row query as operation on entity.
+func scanRow(ctx context.Context, db execer, entity, operation, query string, args []interface{}, dest ...interface{}) error {
+	ctx, o := begin(ctx, entity, operation, query)
+	err := db.QueryRowContext(ctx, query, args...).Scan(dest...)
+	rows := 1
+	if err != nil {
+		rows = 0
+	}
+	o.end(rows, err)
+	return err
+}
+
+// queryRows ends the operation of its query once closed, counting the rows
+// read meanwhile.
+type queryRows struct {
+	*sql.Rows
+	op     *op
+	n      int
+	closed bool
+}
+
+func (r *queryRows) Next() bool {
+	if r.Rows.Next() {
+		r.n++
+		return true
+	}
+	return false
+}
+
+func (r *queryRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.op.end(r.n, r.Rows.Err())
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestShape(t *testing.T) {
+	for _, tt := range []struct{ query, want string }{
+		{"SELECT id FROM books WHERE id = ?", "SELECT id FROM books WHERE id = ?"},
+		{"SELECT id FROM books WHERE id = 42", "SELECT id FROM books WHERE id = ?"},
+		{"SELECT id FROM tags WHERE name = 'new' AND id = 3", "SELECT id FROM tags WHERE name = ? AND id = ?"},
+		{`SELECT id FROM tags WHERE name = 'it\'s' LIMIT 10`, "SELECT id FROM tags WHERE name = ? LIMIT ?"},
+		{"SELECT id FROM books WHERE id IN(1, 2, 3)", "SELECT id FROM books WHERE id IN(?)"},
+		{"SELECT id FROM books WHERE id IN(?, ?)", "SELECT id FROM books WHERE id IN(?)"},
+		// digits inside identifiers are not literals
+		{"SELECT v2 FROM t1 WHERE v2 = 7", "SELECT v2 FROM t1 WHERE v2 = ?"},
+	} {
+		if got := shape(tt.query); got != tt.want {
+			t.Errorf("shape(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	for _, tt := range []struct{ query, want string }{
+		{"SELECT id FROM books WHERE id = 1 ORDER BY id LIMIT ? OFFSET ?", "id = ?"},
+		{"SELECT id, MAX(x) FROM tags WHERE name LIKE '%a%' GROUP BY id", "name LIKE ?"},
+		{"SELECT id FROM books", ""},
+	} {
+		var got string
+		if m := where.FindStringSubmatch(tt.query); m != nil {
+			got = shape(m[1])
+		}
+		if got != tt.want {
+			t.Errorf("filter of %q = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Key is an API key. Only the SHA-256 hash of the key is stored.
+type Key struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+const keyTable = `CREATE TABLE IF NOT EXISTS api_keys (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	hash CHAR(64) NOT NULL UNIQUE,
+	scopes VARCHAR(255) NOT NULL,
+	created_at DATETIME NOT NULL,
+	revoked_at DATETIME NULL
+)`
+
+// CreateKeyTable creates the api_keys table if it does not exist yet.
+func (d *DAO) CreateKeyTable(ctx context.Context) error {
+	_, err := exec(ctx, d.DB, "api_keys", "create_table", keyTable)
+	return err
+}
+
+// CreateKey stores key and sets its ID.
+func (d *DAO) CreateKey(ctx context.Context, key *Key) error {
+	res, err := exec(ctx, d.DB, "api_keys", "create", "INSERT INTO api_keys (name, hash, scopes, created_at) VALUES (?, ?, ?, ?)",
+		key.Name, key.Hash, strings.Join(key.Scopes, " "), key.CreatedAt)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	key.ID = int(id)
+	return nil
+}
+
+// GetKeyByHash returns the key with the given hash, or nil if there is none
+// or it was revoked.
+func (d *DAO) GetKeyByHash(ctx context.Context, hash string) (*Key, error) {
+	rows, err := d.query(ctx, "api_keys", "get", "SELECT id, name, hash, scopes, created_at, revoked_at FROM api_keys WHERE hash = ? AND revoked_at IS NULL", hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys, err := handleKeys(rows)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+
+	return &keys[0], nil
+}
+
+// GetKeys .
+func (d *DAO) GetKeys(ctx context.Context) ([]Key, error) {
+	rows, err := d.query(ctx, "api_keys", "list", "SELECT id, name, hash, scopes, created_at, revoked_at FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return handleKeys(rows)
+}
+
+// RevokeKey marks the key with the given id as revoked, or returns
+// ErrNotFound when there is no such active key.
+func (d *DAO) RevokeKey(ctx context.Context, id int) error {
+	res, err := exec(ctx, d.DB, "api_keys", "revoke", "UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+	return found(res)
+}
+
+func handleKeys(rows *queryRows) ([]Key, error) {
+	var keys []Key
+	for rows.Next() {
+		var key Key
+		var scopes string
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Name, &key.Hash, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		key.Scopes = strings.Fields(scopes)
+		if revokedAt.Valid {
+			t := revokedAt.Time
+			key.RevokedAt = &t
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
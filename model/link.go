@@ -0,0 +1,121 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LinkCheck is the outcome of the last check of a link of a book.
+type LinkCheck struct {
+	BookID int    `json:"book_id" example:"42"`
+	Title  string `json:"title,omitempty" example:"Laskar Pelangi"`
+	// Field is the column holding the link, image_url or gramed_url.
+	Field string `json:"field" example:"image_url"`
+	URL   string `json:"url" example:"https://cdn.gramedia.com/uploads/items/laskar.jpg"`
+	// Status is the HTTP status answered, 0 when the request failed.
+	Status    int       `json:"status" example:"404"`
+	Error     string    `json:"error,omitempty" example:"Not Found"`
+	Broken    bool      `json:"broken" example:"true"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+const linkTable = `CREATE TABLE IF NOT EXISTS link_checks (
+	book_id INT NOT NULL,
+	field VARCHAR(16) NOT NULL,
+	url VARCHAR(2048) NOT NULL,
+	status INT NOT NULL,
+	message VARCHAR(255) NOT NULL,
+	broken BOOLEAN NOT NULL,
+	checked_at DATETIME NOT NULL,
+	PRIMARY KEY (book_id, field)
+)`
+
+// brokenLink matches the broken links of l that the book b still points at,
+// the checks of replaced links being stale.
+const brokenLink = `l.broken AND ((l.field = 'image_url' AND l.url = b.image_url) OR (l.field = 'gramed_url' AND l.url = b.gramed_url))`
+
+// CreateLinkTable creates the link_checks table if it does not exist yet.
+func (d *DAO) CreateLinkTable(ctx context.Context) error {
+	_, err := exec(ctx, d.DB, "link_checks", "create_table", linkTable)
+	return err
+}
+
+// SaveLinkCheck stores check, replacing the previous check of the link.
+func (d *DAO) SaveLinkCheck(ctx context.Context, check *LinkCheck) error {
+	message := check.Error
+	if len(message) > 255 {
+		message = message[:255]
+	}
+	_, err := exec(ctx, d.DB, "link_checks", "save", "REPLACE INTO link_checks (book_id, field, url, status, message, broken, checked_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		check.BookID, check.Field, check.URL, check.Status, message, check.Broken, check.CheckedAt)
+	return err
+}
+
+// LastLinkCheck returns when a link was last checked, the zero time when none
+// was.
+func (d *DAO) LastLinkCheck(ctx context.Context) (time.Time, error) {
+	// rather than MAX, which loses the type of the column in some drivers
+	var last time.Time
+	err := scanRow(ctx, d.DB, "link_checks", "last", "SELECT checked_at FROM link_checks ORDER BY checked_at DESC LIMIT 1", nil, &last)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return last, err
+}
+
+// GetBrokenLinks returns a page of the broken links, ordered by book.
+func (d *DAO) GetBrokenLinks(ctx context.Context, limit, offset int) ([]LinkCheck, error) {
+	rows, err := d.query(ctx, "link_checks", "broken",
+		"SELECT l.book_id, b.title, l.field, l.url, l.status, l.message, l.broken, l.checked_at FROM link_checks l JOIN books b ON b.id = l.book_id WHERE "+brokenLink+" ORDER BY l.book_id, l.field LIMIT ? OFFSET ?",
+		limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []LinkCheck
+	for rows.Next() {
+		var l LinkCheck
+		if err := rows.Scan(&l.BookID, &l.Title, &l.Field, &l.URL, &l.Status, &l.Error, &l.Broken, &l.CheckedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// MarkBrokenImages sets ImageBroken on the books whose image link was found
+// broken.
+func (d *DAO) MarkBrokenImages(ctx context.Context, books []Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	ids := make([]string, len(books))
+	for i, book := range books {
+		ids[i] = fmt.Sprint(book.ID)
+	}
+
+	rows, err := d.query(ctx, "link_checks", "broken_images", fmt.Sprintf(
+		"SELECT l.book_id FROM link_checks l JOIN books b ON b.id = l.book_id WHERE l.field = 'image_url' AND %s AND l.book_id IN (%s)",
+		brokenLink, strings.Join(ids, ", ")))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	broken := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		broken[id] = true
+	}
+	for i := range books {
+		books[i].ImageBroken = broken[books[i].ID]
+	}
+	return rows.Err()
+}
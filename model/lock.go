@@ -0,0 +1,38 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+)
+
+// TryLock takes the named advisory lock without waiting, reporting whether it
+// was free. The lock is held by a connection of its own, until unlock is
+// called or the connection is lost.
+func (d *DAO) TryLock(ctx context.Context, name string) (ok bool, unlock func(), err error) {
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	var taken sql.NullInt64
+	if err := scanRow(ctx, conn, "locks", "get", "SELECT GET_LOCK(?, 0)", []interface{}{name}, &taken); err != nil {
+		conn.Close()
+		return false, nil, err
+	}
+	if taken.Int64 != 1 {
+		conn.Close()
+		return false, nil, nil
+	}
+	return true, func() {
+		var released sql.NullInt64
+		err := scanRow(context.Background(), conn, "locks", "release", "SELECT RELEASE_LOCK(?)", []interface{}{name}, &released)
+		if err != nil || released.Int64 != 1 {
+			// back in the pool, the connection would keep the lock for
+			// good, while discarding it releases the lock
+			slog.Warn("cannot release lock, closing its connection", "lock", name, "error", err)
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"modernc.org/sqlite"
+)
+
+// locks stands in for the advisory locks of MySQL, releasing fails for the
+// names in unreleasable.
+var locks = struct {
+	sync.Mutex
+	held, unreleasable map[string]bool
+}{held: map[string]bool{}, unreleasable: map[string]bool{}}
+
+func init() {
+	sqlite.MustRegisterScalarFunction("GET_LOCK", 2, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		locks.Lock()
+		defer locks.Unlock()
+		name := args[0].(string)
+		if locks.held[name] {
+			return int64(0), nil
+		}
+		locks.held[name] = true
+		return int64(1), nil
+	})
+	sqlite.MustRegisterScalarFunction("RELEASE_LOCK", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		locks.Lock()
+		defer locks.Unlock()
+		name := args[0].(string)
+		if locks.unreleasable[name] {
+			return nil, nil
+		}
+		delete(locks.held, name)
+		return int64(1), nil
+	})
+}
+
+func TestTryLock(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "lock.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	d := &DAO{DB: db}
+	ctx := context.Background()
+
+	ok, unlock, err := d.TryLock(ctx, "released")
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	if ok, _, err := d.TryLock(ctx, "released"); err != nil || ok {
+		t.Fatalf("TryLock of a held lock = %v, %v", ok, err)
+	}
+	unlock()
+	if db.Stats().Idle != 2 {
+		t.Errorf("%d idle connections after unlock, want the 2 used", db.Stats().Idle)
+	}
+
+	locks.Lock()
+	locks.unreleasable["kept"] = true
+	locks.Unlock()
+	ok, unlock, err = d.TryLock(ctx, "kept")
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	unlock()
+	// the connection still holding the lock is not reused
+	if n := db.Stats().OpenConnections; n != 1 {
+		t.Errorf("%d open connections after a failed release, want 1", n)
+	}
+}
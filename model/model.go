@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Book .
 type Book struct {
 	ID          int    `json:"id,omitempty"`
@@ -10,6 +12,15 @@ type Book struct {
 	Authors     []Item `json:"authors,omitempty"`
 	Categories  []Item `json:"categories,omitempty"`
 	Tags        []Item `json:"tags,omitempty"`
+
+	// ImageBroken is set by MarkBrokenImages for the pages.
+	ImageBroken bool `json:"-"`
+
+	// CreatedAt and UpdatedAt date the book for the feeds, CreatedAt being
+	// nil for the books that came with the data and UpdatedAt until the book
+	// changes.
+	CreatedAt *time.Time `json:"-"`
+	UpdatedAt *time.Time `json:"-"`
 }
 
 // Item .
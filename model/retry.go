@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/kautsarady/adindopustaka/logging"
+)
+
+// Options tunes the connection pool of the DAO.
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// ConnectTimeout bounds the startup connectivity check, which is retried
+	// with exponential backoff meanwhile.
+	ConnectTimeout time.Duration
+
+	// ReadRetries is how many times a read failing with a transient error is
+	// retried.
+	ReadRetries int
+}
+
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 5 * time.Second
+)
+
+// connect pings db until it answers, giving up after timeout, when ctx is
+// done or on the first error which retrying cannot fix, like bad credentials.
+func connect(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	for wait := minBackoff; ; wait = backoff(wait) {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() == nil && !transient(err) {
+			return err
+		}
+		if ctx.Err() != nil || time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("database unreachable after %s: %v", time.Since(start).Round(time.Millisecond), err)
+		}
+		slog.Warn("database unreachable", "retry_in", wait.String(), "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func backoff(wait time.Duration) time.Duration {
+	if wait *= 2; wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+// retry runs the read fn again, up to ReadRetries times, while it fails with
+// a transient error and ctx is not done. fn must be safe to repeat.
+func (d *DAO) retry(ctx context.Context, fn func() error) error {
+	wait := minBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= d.ReadRetries || !transient(err) || ctx.Err() != nil {
+			return err
+		}
+
+		logging.FromContext(ctx).Warn("retrying transient failure", "attempt", attempt+1, "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		wait = backoff(wait)
+	}
+}
+
+// query runs a read query as operation on entity, retrying transient
+// failures. The operation ends when the rows are closed.
+func (d *DAO) query(ctx context.Context, entity, operation, query string, args ...interface{}) (*queryRows, error) {
+	ctx, o := begin(ctx, entity, operation, query)
+	var rows *sql.Rows
+	err := d.retry(ctx, func() (err error) {
+		rows, err = d.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		o.end(-1, err)
+		return nil, err
+	}
+	return &queryRows{Rows: rows, op: o}, nil
+}
+
+// transient reports whether err is likely to go away on its own: a dropped
+// or refused connection, a timeout, an overloaded server or a lock conflict.
+func transient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1040, // too many connections
+			1205, // lock wait timeout
+			1213: // deadlock
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
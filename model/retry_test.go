@@ -0,0 +1,156 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTransient(t *testing.T) {
+	for _, tt := range []struct {
+		err       error
+		transient bool
+	}{
+		{driver.ErrBadConn, true},
+		{mysql.ErrInvalidConn, true},
+		{io.EOF, true},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{&mysql.MySQLError{Number: 1040}, true},
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1045}, false},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{sql.ErrNoRows, false},
+		{context.Canceled, false},
+	} {
+		if got := transient(tt.err); got != tt.transient {
+			t.Errorf("transient(%v) = %v, want %v", tt.err, got, tt.transient)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	wait := minBackoff
+	var waits []time.Duration
+	for i := 0; i < 8; i++ {
+		waits = append(waits, wait)
+		wait = backoff(wait)
+	}
+	want := []time.Duration{100, 200, 400, 800, 1600, 3200, 5000, 5000}
+	for i := range want {
+		if waits[i] != want[i]*time.Millisecond {
+			t.Fatalf("backoff = %v", waits)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		retries  int
+		failures int
+		err      error
+		calls    int
+		fails    bool
+	}{
+		{"success", 2, 0, driver.ErrBadConn, 1, false},
+		{"transient", 2, 2, driver.ErrBadConn, 3, false},
+		{"out of retries", 1, 2, driver.ErrBadConn, 2, true},
+		{"permanent", 2, 2, &mysql.MySQLError{Number: 1146}, 1, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DAO{ReadRetries: tt.retries}
+			calls := 0
+			err := d.retry(context.Background(), func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+			if calls != tt.calls || (err != nil) != tt.fails {
+				t.Errorf("retry = %v after %d calls, want %d calls", err, calls, tt.calls)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	(&DAO{ReadRetries: 5}).retry(ctx, func() error {
+		calls++
+		return driver.ErrBadConn
+	})
+	if calls != 1 {
+		t.Errorf("retry after cancel called fn %d times", calls)
+	}
+}
+
+// flakyDriver fails to connect with err until failures connections were
+// attempted.
+type flakyDriver struct {
+	failures int32
+	err      error
+	attempts int32
+}
+
+func (d *flakyDriver) Open(string) (driver.Conn, error) {
+	if atomic.AddInt32(&d.attempts, 1) <= d.failures {
+		return nil, d.err
+	}
+	return flakyConn{}, nil
+}
+
+type flakyConn struct{}
+
+func (flakyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (flakyConn) Close() error                        { return nil }
+func (flakyConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+var flakyDrivers int32
+
+func flakyDB(failures int32, err error) (*sql.DB, *flakyDriver) {
+	d := &flakyDriver{failures: failures, err: err}
+	name := fmt.Sprintf("flaky%d", atomic.AddInt32(&flakyDrivers, 1))
+	sql.Register(name, d)
+	db, _ := sql.Open(name, "")
+	return db, d
+}
+
+func TestConnect(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+
+	db, d := flakyDB(2, refused)
+	if err := connect(context.Background(), db, 5*time.Second); err != nil || d.attempts != 3 {
+		t.Errorf("connect = %v after %d attempts, want success after 3", err, d.attempts)
+	}
+
+	db, d = flakyDB(100, &mysql.MySQLError{Number: 1045, Message: "access denied"})
+	if err := connect(context.Background(), db, 5*time.Second); err == nil || d.attempts != 1 {
+		t.Errorf("connect with bad credentials = %v after %d attempts, want failure after 1", err, d.attempts)
+	}
+
+	db, _ = flakyDB(100, refused)
+	start := time.Now()
+	if err := connect(context.Background(), db, 250*time.Millisecond); err == nil || time.Since(start) > time.Second {
+		t.Errorf("connect past its timeout = %v after %s", err, time.Since(start))
+	}
+
+	// a signal during startup stops the wait
+	db, _ = flakyDB(100, refused)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(150*time.Millisecond, cancel)
+	start = time.Now()
+	if err := connect(ctx, db, time.Minute); err == nil || time.Since(start) > time.Second {
+		t.Errorf("connect after cancel = %v after %s", err, time.Since(start))
+	}
+}
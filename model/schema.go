@@ -0,0 +1,75 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tables lists the tables the service needs. The catalog tables come with the
+// data, the others are created at startup.
+var Tables = []string{"books", "authors", "categories", "tags", "api_keys", "admin_users"}
+
+// Ping checks that the database answers.
+func (d *DAO) Ping(ctx context.Context) error {
+	return d.DB.PingContext(ctx)
+}
+
+// CheckSchema fails when any of Tables is missing from the database.
+func (d *DAO) CheckSchema(ctx context.Context) error {
+	rows, err := d.query(ctx, "information_schema", "check_schema", "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[strings.ToLower(name)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, table := range Tables {
+		if !existing[table] {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing tables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// bookMigrations are the columns the service adds to the books table, which
+// comes with the data, in order.
+var bookMigrations = []struct{ column, definition string }{
+	{"updated_at", "DATETIME NULL"},
+	// the books already there are undated rather than all new at once
+	{"created_at", "DATETIME NULL"},
+}
+
+// MigrateBooks adds the missing bookMigrations columns to the books table.
+func (d *DAO) MigrateBooks(ctx context.Context) error {
+	for _, m := range bookMigrations {
+		var n int
+		if err := scanRow(ctx, d.DB, "information_schema", "check_column",
+			"SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'books' AND column_name = ?",
+			[]interface{}{m.column}, &n); err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := exec(ctx, d.DB, "books", "migrate", fmt.Sprintf("ALTER TABLE books ADD COLUMN %s %s", m.column, m.definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
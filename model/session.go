@@ -0,0 +1,64 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const sessionTable = `CREATE TABLE IF NOT EXISTS admin_sessions (
+	id CHAR(64) PRIMARY KEY,
+	username VARCHAR(255) NOT NULL,
+	expires_at DATETIME NOT NULL,
+	KEY (username),
+	KEY (expires_at)
+)`
+
+// CreateSessionTable creates the admin_sessions table if it does not exist
+// yet.
+func (d *DAO) CreateSessionTable(ctx context.Context) error {
+	_, err := exec(ctx, d.DB, "admin_sessions", "create_table", sessionTable)
+	return err
+}
+
+// CreateSession records the console session id of username until expires,
+// dropping the expired sessions along the way.
+func (d *DAO) CreateSession(ctx context.Context, id, username string, expires time.Time) error {
+	if _, err := exec(ctx, d.DB, "admin_sessions", "expire", "DELETE FROM admin_sessions WHERE expires_at < ?", now()); err != nil {
+		return err
+	}
+	_, err := exec(ctx, d.DB, "admin_sessions", "create", "INSERT INTO admin_sessions (id, username, expires_at) VALUES (?, ?, ?)",
+		id, username, expires.UTC())
+	return err
+}
+
+// SessionExists reports whether the session id was recorded and has neither
+// expired nor been deleted.
+func (d *DAO) SessionExists(ctx context.Context, id string) (bool, error) {
+	var username string
+	err := d.retry(ctx, func() error {
+		return scanRow(ctx, d.DB, "admin_sessions", "get", "SELECT username FROM admin_sessions WHERE id = ? AND expires_at >= ?",
+			[]interface{}{id, now()}, &username)
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteSession ends the session id.
+func (d *DAO) DeleteSession(ctx context.Context, id string) error {
+	_, err := exec(ctx, d.DB, "admin_sessions", "delete", "DELETE FROM admin_sessions WHERE id = ?", id)
+	return err
+}
+
+// DeleteUserSessions ends every session of username and returns how many
+// there were.
+func (d *DAO) DeleteUserSessions(ctx context.Context, username string) (int, error) {
+	res, err := exec(ctx, d.DB, "admin_sessions", "delete_by_user", "DELETE FROM admin_sessions WHERE username = ?", username)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
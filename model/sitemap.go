@@ -0,0 +1,82 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Chunk is a range of ids of an entity, the rows with ids from
+// Index*size+1 to (Index+1)*size, which the sitemaps list together.
+type Chunk struct {
+	Index int
+	// Modified is the last update of a book of the chunk, nil when unknown.
+	Modified *time.Time
+}
+
+// modifiedFrom returns the FROM clause selecting the rows of entity, aliased
+// as i, and the expression of the last update of their books.
+func modifiedFrom(entity string) (from, modified string, err error) {
+	if entity == "books" {
+		return "books i", "COALESCE(i.updated_at, i.created_at)", nil
+	}
+	if !IsRelation(entity) {
+		return "", "", unknownRelation(entity)
+	}
+	return fmt.Sprintf("%s i LEFT JOIN books b ON b.id = i.book_id", entity), "COALESCE(b.updated_at, b.created_at)", nil
+}
+
+// GetChunks returns the chunks of size ids of entity having rows, in order.
+func (d *DAO) GetChunks(ctx context.Context, entity string, size int) ([]Chunk, error) {
+	from, modified, err := modifiedFrom(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.query(ctx, entity, "chunks", fmt.Sprintf(
+		"SELECT FLOOR((i.id - 1) / ?) AS chunk, MAX(%s) FROM %s GROUP BY chunk ORDER BY chunk", modified, from), size)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chunks []Chunk
+	for rows.Next() {
+		var c Chunk
+		if err := rows.Scan(&c.Index, &c.Modified); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, rows.Err()
+}
+
+// EachModified calls fn, in order, with the id of every row of entity in
+// chunk and the last update of its books, nil when unknown. The rows are
+// streamed rather than read at once, an error of fn stopping them.
+func (d *DAO) EachModified(ctx context.Context, entity string, chunk, size int, fn func(id int, modified *time.Time) error) error {
+	from, modified, err := modifiedFrom(entity)
+	if err != nil {
+		return err
+	}
+
+	rows, err := d.query(ctx, entity, "modified", fmt.Sprintf(
+		"SELECT i.id, MAX(%s) FROM %s WHERE i.id BETWEEN ? AND ? GROUP BY i.id ORDER BY i.id", modified, from),
+		chunk*size+1, (chunk+1)*size)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var at *time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return err
+		}
+		if err := fn(id, at); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+)
+
+// User is an admin console account.
+type User struct {
+	ID           int
+	Username     string
+	PasswordHash string
+	Role         string
+}
+
+const userTable = `CREATE TABLE IF NOT EXISTS admin_users (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	username VARCHAR(255) NOT NULL UNIQUE,
+	password_hash VARCHAR(255) NOT NULL,
+	role VARCHAR(32) NOT NULL
+)`
+
+// CreateUserTable creates the admin_users table if it does not exist yet.
+func (d *DAO) CreateUserTable(ctx context.Context) error {
+	_, err := exec(ctx, d.DB, "admin_users", "create_table", userTable)
+	return err
+}
+
+// CreateUser stores user and sets its ID.
+func (d *DAO) CreateUser(ctx context.Context, user *User) error {
+	res, err := exec(ctx, d.DB, "admin_users", "create", "INSERT INTO admin_users (username, password_hash, role) VALUES (?, ?, ?)",
+		user.Username, user.PasswordHash, user.Role)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	user.ID = int(id)
+	return nil
+}
+
+// GetUserByUsername returns nil when there is no such user.
+func (d *DAO) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	var user User
+	err := d.retry(ctx, func() error {
+		return scanRow(ctx, d.DB, "admin_users", "get", "SELECT id, username, password_hash, role FROM admin_users WHERE username = ?",
+			[]interface{}{username}, &user.ID, &user.Username, &user.PasswordHash, &user.Role)
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -6,6 +6,21 @@ import (
 	"strings"
 )
 
+var bookColumns = []string{"id", "title", "image_url", "gramed_url", "description", "created_at", "updated_at"}
+
+// Relations lists the entities that can be attached to a book.
+var Relations = []string{"authors", "categories", "tags"}
+
+// IsRelation reports whether entity is one of Relations.
+func IsRelation(entity string) bool {
+	for _, relation := range Relations {
+		if relation == entity {
+			return true
+		}
+	}
+	return false
+}
+
 // ToBooks .
 func ToBooks(result []interface{}) (books []Book) {
 	for _, book := range result {
@@ -32,6 +47,15 @@ func ItemAndIDs(result []interface{}) (Item, []string) {
 	return items[0], IDs
 }
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func selectValue(columns []string) string {
+	if columns == nil {
+		return "*"
+	}
+	return strings.Join(columns, ", ")
+}
+
 func whereValue(filter []string) string {
 	if filter == nil {
 		return "1"
@@ -39,10 +63,42 @@ func whereValue(filter []string) string {
 	return strings.Join(filter, " AND ")
 }
 
-func handleBooks(result *[]interface{}, rows *sql.Rows) error {
+// Sorts are the accepted sort values of each entity, a column optionally
+// prefixed with "-" for descending order.
+var Sorts = map[string][]string{
+	"books":      {"id", "-id", "title", "-title"},
+	"authors":    {"id", "-id", "name", "-name"},
+	"categories": {"id", "-id", "name", "-name"},
+	"tags":       {"id", "-id", "name", "-name"},
+}
+
+func orderValue(entity, sort string) (string, error) {
+	if sort == "" {
+		return "", nil
+	}
+	for _, s := range Sorts[entity] {
+		if s == sort {
+			if strings.HasPrefix(sort, "-") {
+				return " ORDER BY " + sort[1:] + " DESC", nil
+			}
+			return " ORDER BY " + sort, nil
+		}
+	}
+	return "", Invalid("sort", "must be one of %s", strings.Join(Sorts[entity], ", "))
+}
+
+func handleBooks(result *[]interface{}, rows *queryRows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
 	for rows.Next() {
 		var book Book
-		if err := rows.Scan(&book.ID, &book.Title, &book.ImageURL, &book.GramedURL, &book.Description); err != nil {
+		dest := make([]interface{}, len(columns))
+		for i, column := range columns {
+			dest[i] = bookField(&book, column)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return err
 		}
 		*result = append(*result, book)
@@ -50,7 +106,7 @@ func handleBooks(result *[]interface{}, rows *sql.Rows) error {
 	return nil
 }
 
-func handleItems(result *[]interface{}, rows *sql.Rows) error {
+func handleItems(result *[]interface{}, rows *queryRows) error {
 	for rows.Next() {
 		var item Item
 		if err := rows.Scan(&item.ID, &item.BookID, &item.Name); err != nil {
@@ -61,3 +117,35 @@ func handleItems(result *[]interface{}, rows *sql.Rows) error {
 	}
 	return nil
 }
+
+// RelationOf .
+func RelationOf(book *Book, relation string) *[]Item {
+	switch relation {
+	case "authors":
+		return &book.Authors
+	case "categories":
+		return &book.Categories
+	default:
+		return &book.Tags
+	}
+}
+
+func bookField(book *Book, column string) interface{} {
+	switch column {
+	case "id":
+		return &book.ID
+	case "title":
+		return &book.Title
+	case "image_url":
+		return &book.ImageURL
+	case "gramed_url":
+		return &book.GramedURL
+	case "description":
+		return &book.Description
+	case "created_at":
+		return &book.CreatedAt
+	case "updated_at":
+		return &book.UpdatedAt
+	}
+	return new(sql.RawBytes)
+}
@@ -0,0 +1,276 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CreateBook stores book and sets its ID.
+func (d *DAO) CreateBook(ctx context.Context, book *Book) error {
+	if err := validBook(book); err != nil {
+		return err
+	}
+	created := now()
+	res, err := exec(ctx, d.DB, "books", "create", "INSERT INTO books (title, image_url, gramed_url, description, created_at) VALUES (?, ?, ?, ?, ?)",
+		book.Title, book.ImageURL, book.GramedURL, book.Description, created)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	book.ID = int(id)
+	book.CreatedAt = &created
+	return nil
+}
+
+// UpdateBook overwrites the columns of the book with book.ID.
+func (d *DAO) UpdateBook(ctx context.Context, book *Book) error {
+	if err := validBook(book); err != nil {
+		return err
+	}
+	res, err := exec(ctx, d.DB, "books", "update", "UPDATE books SET title = ?, image_url = ?, gramed_url = ?, description = ?, updated_at = ? WHERE id = ?",
+		book.Title, book.ImageURL, book.GramedURL, book.Description, now(), book.ID)
+	if err != nil {
+		return err
+	}
+	return found(res)
+}
+
+// SetBookImage points the image_url of the book with id at imageURL, leaving
+// its other columns as they are.
+func (d *DAO) SetBookImage(ctx context.Context, id int, imageURL string) error {
+	res, err := exec(ctx, d.DB, "books", "set_image", "UPDATE books SET image_url = ?, updated_at = ? WHERE id = ?",
+		imageURL, now(), id)
+	if err != nil {
+		return err
+	}
+	return found(res)
+}
+
+// DeleteBook deletes a book along with its relations.
+func (d *DAO) DeleteBook(ctx context.Context, id int) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, relation := range Relations {
+		if _, err := exec(ctx, tx, relation, "delete_by_book", fmt.Sprintf("DELETE FROM %s WHERE book_id = ?", relation), id); err != nil {
+			return err
+		}
+	}
+
+	res, err := exec(ctx, tx, "books", "delete", "DELETE FROM books WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := found(res); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// itemLockTable holds a row per relation, which AddItem and RenameItem lock
+// while they look up the item of a name and pick the id of a new one. The ids repeat across the rows
+// of a relation, so that AUTO_INCREMENT cannot pick them.
+const itemLockTable = `CREATE TABLE IF NOT EXISTS item_locks (
+	entity VARCHAR(32) PRIMARY KEY
+)`
+
+// CreateItemLockTable creates the item_locks table if it does not exist yet.
+func (d *DAO) CreateItemLockTable(ctx context.Context) error {
+	_, err := exec(ctx, d.DB, "item_locks", "create_table", itemLockTable)
+	return err
+}
+
+// AddItem relates the item of entity named name to a book, creating the
+// item when no item has that name yet.
+func (d *DAO) AddItem(ctx context.Context, entity string, bookID int, name string) error {
+	if !IsRelation(entity) {
+		return unknownRelation(entity)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return Invalid("name", "is required")
+	}
+
+	// concurrent additions would otherwise both miss a new name, or both
+	// pick the same next id
+	tx, err := d.lockItems(ctx, entity)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = scanRow(ctx, tx, entity, "find_by_name", fmt.Sprintf("SELECT id FROM %s WHERE name = ? LIMIT 1", entity), []interface{}{name}, &id)
+	if err == sql.ErrNoRows {
+		err = scanRow(ctx, tx, entity, "next_id", fmt.Sprintf("SELECT COALESCE(MAX(id), 0) + 1 FROM %s", entity), nil, &id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := exec(ctx, tx, entity, "add", fmt.Sprintf("INSERT INTO %s (id, book_id, name) VALUES (?, ?, ?)", entity), id, bookID, name); err != nil {
+		return err
+	}
+	if err := touchBooks(ctx, tx, "id = ?", bookID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockItems begins a transaction holding the lock of the items of entity. The
+// lock row is inserted out of the transaction, whose shared lock on an
+// existing row would deadlock with the FOR UPDATE of another.
+func (d *DAO) lockItems(ctx context.Context, entity string) (*sql.Tx, error) {
+	if _, err := exec(ctx, d.DB, "item_locks", "create", "INSERT IGNORE INTO item_locks (entity) VALUES (?)", entity); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var locked string
+	if err := scanRow(ctx, tx, "item_locks", "lock", "SELECT entity FROM item_locks WHERE entity = ? FOR UPDATE", []interface{}{entity}, &locked); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// RemoveItem removes the relation between an item of entity and a book.
+func (d *DAO) RemoveItem(ctx context.Context, entity string, id, bookID int) error {
+	if !IsRelation(entity) {
+		return unknownRelation(entity)
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := exec(ctx, tx, entity, "remove", fmt.Sprintf("DELETE FROM %s WHERE id = ? AND book_id = ?", entity), id, bookID)
+	if err != nil {
+		return err
+	}
+	if err := found(res); err != nil {
+		return err
+	}
+	if err := touchBooks(ctx, tx, "id = ?", bookID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RenameItem renames an item of entity, which fails with ErrConflict when
+// another item has the name.
+func (d *DAO) RenameItem(ctx context.Context, entity string, id int, name string) error {
+	if !IsRelation(entity) {
+		return unknownRelation(entity)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return Invalid("name", "is required")
+	}
+
+	// two items of a name would make the item AddItem finds ambiguous
+	tx, err := d.lockItems(ctx, entity)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var other int
+	err = scanRow(ctx, tx, entity, "find_by_name", fmt.Sprintf("SELECT id FROM %s WHERE name = ? AND id <> ? LIMIT 1", entity), []interface{}{name, id}, &other)
+	if err == nil {
+		return fmt.Errorf("%w: %s %d is already named %s", ErrConflict, entity, other, name)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	res, err := exec(ctx, tx, entity, "rename", fmt.Sprintf("UPDATE %s SET name = ? WHERE id = ?", entity), name, id)
+	if err != nil {
+		return err
+	}
+	if err := found(res); err != nil {
+		return err
+	}
+	if err := touchBooks(ctx, tx, fmt.Sprintf("id IN (SELECT book_id FROM %s WHERE id = ?)", entity), id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteItem deletes an item of entity from every book.
+func (d *DAO) DeleteItem(ctx context.Context, entity string, id int) error {
+	if !IsRelation(entity) {
+		return unknownRelation(entity)
+	}
+
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the books lose the item, touch them while they still have it
+	if err := touchBooks(ctx, tx, fmt.Sprintf("id IN (SELECT book_id FROM %s WHERE id = ?)", entity), id); err != nil {
+		return err
+	}
+	res, err := exec(ctx, tx, entity, "delete", fmt.Sprintf("DELETE FROM %s WHERE id = ?", entity), id)
+	if err != nil {
+		return err
+	}
+	if err := found(res); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// touchBooks sets the updated_at of the books matching where, whose pages
+// changed along with their relations.
+func touchBooks(ctx context.Context, db execer, where string, args ...interface{}) error {
+	_, err := exec(ctx, db, "books", "touch", "UPDATE books SET updated_at = ? WHERE "+where, append([]interface{}{now()}, args...)...)
+	return err
+}
+
+// now is the time stored in the DATETIME columns, which keep no fraction of
+// a second.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// found returns ErrNotFound when res affected no row.
+func found(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// validBook .
+func validBook(book *Book) error {
+	if strings.TrimSpace(book.Title) == "" {
+		return Invalid("title", "is required")
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/httputil"
+	"github.com/kautsarady/adindopustaka/logging"
+)
+
+// Result is the state of a bucket after taking a token from it.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until a token is available again.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps token buckets. Implementations must be safe for concurrent use;
+// sharing one Store between replicas makes them enforce a single limit.
+type Store interface {
+	// Take removes a token from the bucket named key, refilled at rate tokens
+	// per second up to burst tokens.
+	Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (Result, error)
+}
+
+// Limiter is a token bucket rate limit keyed by the principal verified by
+// auth.Optional or auth.Require, when either runs first, or by client IP.
+// Limiters running before the credentials are checked bound the attempts
+// with made up ones. A nil Limiter does not limit anything.
+type Limiter struct {
+	// Name separates the buckets of limiters sharing a Store.
+	Name  string
+	Store Store
+	// Rate is the sustained number of requests per second.
+	Rate float64
+	// Burst is the bucket size.
+	Burst int
+	// TrustedProxies are allowed to report the client IP in
+	// X-Forwarded-For.
+	TrustedProxies []*net.IPNet
+}
+
+// Handler returns the gin middleware enforcing the limit.
+func (l *Limiter) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if l == nil {
+			return
+		}
+
+		res, err := l.Store.Take(ctx.Request.Context(), l.Name+":"+l.key(ctx), l.Rate, l.Burst, time.Now())
+		if err != nil {
+			// fail open, an unavailable store must not take the API down
+			ctx.Error(err)
+			logging.FromContext(ctx.Request.Context()).Warn("rate limit store unavailable", "limiter", l.Name, "error", err)
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(l.Burst))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		ctx.Header("RateLimit-Reset", seconds(res.Reset))
+		if !res.Allowed {
+			ctx.Header("Retry-After", seconds(res.RetryAfter))
+			httputil.NewError(ctx, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+			ctx.Abort()
+		}
+	}
+}
+
+// key names the bucket of the caller. Credentials count only once verified,
+// so that made up keys share the bucket of their IP rather than each get a
+// bucket of their own.
+func (l *Limiter) key(ctx *gin.Context) string {
+	if v, ok := ctx.Get(auth.PrincipalKey); ok {
+		principal := v.(*auth.Principal)
+		return principal.Method + ":" + principal.Subject
+	}
+	return "ip:" + l.ClientIP(ctx.Request)
+}
+
+// ClientIP returns the address of the client, as ClientIP with the trusted
+// proxies of the limiter.
+func (l *Limiter) ClientIP(r *http.Request) string {
+	return ClientIP(r, l.TrustedProxies)
+}
+
+// ClientIP returns the address of the client, reading X-Forwarded-For from
+// right to left for as long as the hops are among proxies.
+func ClientIP(r *http.Request, proxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !trusted(proxies, host) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" || net.ParseIP(hop) == nil {
+			break
+		}
+		host = hop
+		if !trusted(proxies, hop) {
+			break
+		}
+	}
+	return host
+}
+
+func trusted(proxies []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCIDRs parses a comma separated list of CIDRs or bare IPs.
+func ParseCIDRs(list string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			if strings.Contains(s, ":") {
+				s += "/128"
+			} else {
+				s += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// take applies the token bucket algorithm to a bucket holding tokens at
+// updated.
+func take(tokens float64, updated time.Time, rate float64, burst int, now time.Time) (float64, Result) {
+	elapsed := now.Sub(updated).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(float64(burst), tokens+elapsed*rate)
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return tokens, result(tokens, allowed, rate, burst)
+}
+
+// result describes a bucket left with tokens.
+func result(tokens float64, allowed bool, rate float64, burst int) Result {
+	res := Result{Allowed: allowed, Remaining: int(tokens)}
+	if !allowed {
+		res.RetryAfter = duration((1 - tokens) / rate)
+	}
+	res.Reset = duration((float64(burst) - tokens) / rate)
+	return res
+}
+
+func duration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
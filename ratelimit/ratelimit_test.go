@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/auth"
+)
+
+func TestTake(t *testing.T) {
+	start := time.Unix(1000, 0)
+	for _, tt := range []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		left    float64
+		want    Result
+	}{
+		{"full", 5, 0, 4, Result{Allowed: true, Remaining: 4, Reset: time.Second}},
+		{"last token", 1, 0, 0, Result{Allowed: true, Remaining: 0, Reset: 5 * time.Second}},
+		{"empty", 0.5, 0, 0.5, Result{Allowed: false, Remaining: 0, RetryAfter: 500 * time.Millisecond, Reset: 4500 * time.Millisecond}},
+		{"refilled", 0, 2 * time.Second, 1, Result{Allowed: true, Remaining: 1, Reset: 4 * time.Second}},
+		{"refilled up to burst", 0, time.Hour, 4, Result{Allowed: true, Remaining: 4, Reset: time.Second}},
+		{"clock going back", 2, -time.Second, 1, Result{Allowed: true, Remaining: 1, Reset: 4 * time.Second}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			left, res := take(tt.tokens, start, 1, 5, start.Add(tt.elapsed))
+			if left != tt.left || res != tt.want {
+				t.Errorf("take = %v, %+v, want %v, %+v", left, res, tt.left, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	for i := 0; i < 3; i++ {
+		res, _ := s.Take(context.Background(), "a", 1, 3, now)
+		if !res.Allowed {
+			t.Fatalf("take %d denied", i+1)
+		}
+	}
+	if res, _ := s.Take(context.Background(), "a", 1, 3, now); res.Allowed || res.RetryAfter != time.Second {
+		t.Errorf("take past burst = %+v", res)
+	}
+	if res, _ := s.Take(context.Background(), "b", 1, 3, now); !res.Allowed {
+		t.Error("another bucket is denied")
+	}
+
+	// a bucket full again is swept
+	s.Take(context.Background(), "c", 1, 3, now.Add(time.Hour))
+	if _, ok := s.buckets["a"]; ok {
+		t.Error("full bucket not swept")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseCIDRs("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &Limiter{TrustedProxies: proxies}
+	for _, tt := range []struct {
+		name, remote, forwarded, want string
+	}{
+		{"direct", "203.0.113.9:1234", "", "203.0.113.9"},
+		{"forged by a client", "203.0.113.9:1234", "198.51.100.1", "203.0.113.9"},
+		{"through a proxy", "10.1.2.3:80", "198.51.100.1", "198.51.100.1"},
+		{"through proxies", "10.1.2.3:80", "198.51.100.1, 192.168.1.1", "198.51.100.1"},
+		{"forged behind a proxy", "10.1.2.3:80", "1.1.1.1, 198.51.100.1", "198.51.100.1"},
+		{"garbage behind a proxy", "10.1.2.3:80", "not-an-ip", "10.1.2.3"},
+		{"only proxies", "10.1.2.3:80", "10.4.5.6", "10.4.5.6"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := l.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := ParseCIDRs(" 10.0.0.0/8,,192.168.1.1, ::1 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range networks {
+		got = append(got, n.String())
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.1/32", "::1/128"}
+	if len(got) != len(want) {
+		t.Fatalf("ParseCIDRs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseCIDRs = %v, want %v", got, want)
+		}
+	}
+
+	if _, err := ParseCIDRs("10.0.0.0/33"); err == nil {
+		t.Error("ParseCIDRs accepted an invalid CIDR")
+	}
+}
+
+func TestLimiterKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := &Limiter{Name: "api", Store: NewMemoryStore(), Rate: 1, Burst: 1}
+	router := gin.New()
+	router.GET("/", func(ctx *gin.Context) {
+		if name := ctx.GetHeader("X-Principal"); name != "" {
+			ctx.Set(auth.PrincipalKey, &auth.Principal{Subject: name, Method: "api_key"})
+		}
+	}, l.Handler(), func(ctx *gin.Context) {})
+	request := func(principal string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		if principal != "" {
+			r.Header.Set("X-Principal", principal)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	for _, tt := range []struct {
+		principal string
+		status    int
+	}{
+		{"", http.StatusOK},
+		{"", http.StatusTooManyRequests},
+		{"reader", http.StatusOK},
+		{"writer", http.StatusOK},
+		{"reader", http.StatusTooManyRequests},
+	} {
+		if status := request(tt.principal); status != tt.status {
+			t.Errorf("request as %q = %d, want %d", tt.principal, status, tt.status)
+		}
+	}
+}
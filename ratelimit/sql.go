@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+const bucketTable = `CREATE TABLE IF NOT EXISTS rate_limits (
+	bucket VARCHAR(191) PRIMARY KEY,
+	tokens DOUBLE NOT NULL,
+	updated_at BIGINT NOT NULL,
+	KEY (updated_at)
+)`
+
+// refilled is the tokens of a bucket refilled until the time of the query,
+// from the arguments burst, now and rate.
+const refilled = "LEAST(?, tokens + GREATEST(? - updated_at, 0) / 1e9 * ?)"
+
+// takeQuery takes a token from a bucket in one statement, creating it full
+// when it does not exist. The assignments of ON DUPLICATE KEY UPDATE read the
+// row as it was, and the outcome of an existing bucket is returned through
+// LAST_INSERT_ID: the tokens left in thousandths, doubled, plus 1 when a token
+// was taken, plus 2 to tell it from the 0 of a new bucket.
+var takeQuery = strings.NewReplacer("{refilled}", refilled).Replace(`INSERT INTO rate_limits (bucket, tokens, updated_at) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE
+	tokens = (LAST_INSERT_ID(2 * FLOOR(1000 * ({refilled} - ({refilled} >= 1))) + ({refilled} >= 1) + 2) - 2) DIV 2 / 1000,
+	updated_at = ?`)
+
+// SQLStore keeps buckets in a MySQL table, so that every replica connected
+// to the database enforces the same limit.
+type SQLStore struct {
+	DB *sql.DB
+
+	mu       sync.Mutex
+	swept    time.Time
+	sweeping bool
+	// refill is the longest a bucket taken from takes to fill up again.
+	refill time.Duration
+}
+
+// NewSQLStore creates the rate_limits table if it does not exist yet.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if _, err := db.Exec(bucketTable); err != nil {
+		return nil, err
+	}
+	return &SQLStore{DB: db}, nil
+}
+
+// Take implements Store.
+func (s *SQLStore) Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (Result, error) {
+	s.sweep(duration(float64(burst)/rate), now)
+
+	bucket := []interface{}{burst, now.UnixNano(), rate}
+	var args []interface{}
+	args = append(args, key, burst-1, now.UnixNano())
+	for i := 0; i < 3; i++ {
+		args = append(args, bucket...)
+	}
+	args = append(args, now.UnixNano())
+	res, err := s.DB.ExecContext(ctx, takeQuery, args...)
+	if err != nil {
+		return Result{}, err
+	}
+	outcome, err := res.LastInsertId()
+	if err != nil {
+		return Result{}, err
+	}
+	if outcome == 0 {
+		return result(float64(burst-1), true, rate, burst), nil
+	}
+	outcome -= 2
+	return result(float64(outcome/2)/1000, outcome%2 == 1, rate, burst), nil
+}
+
+// sweep deletes, in the background, the buckets untouched for longer than any
+// of them takes to fill up again, which a new bucket is as well, at most once
+// a minute. Each replica sweeps, a failed sweep being retried a minute later.
+func (s *SQLStore) sweep(refill time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if refill > s.refill {
+		s.refill = refill
+	}
+	if s.sweeping || now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept, s.sweeping = now, true
+
+	go func(before int64) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if _, err := s.DB.ExecContext(ctx, "DELETE FROM rate_limits WHERE updated_at < ?", before); err != nil {
+			slog.Warn("cannot sweep rate limit buckets", "error", err)
+		}
+		s.mu.Lock()
+		s.sweeping = false
+		s.mu.Unlock()
+	}(now.Add(-s.refill).UnixNano())
+}
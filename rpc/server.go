@@ -0,0 +1,263 @@
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative catalog.proto
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kautsarady/adindopustaka/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements CatalogService on top of the DAO.
+type Server struct {
+	UnimplementedCatalogServiceServer
+	DAO *model.DAO
+}
+
+// NewServer returns a gRPC server with CatalogService registered.
+func NewServer(dao *model.DAO) *grpc.Server {
+	srv := grpc.NewServer()
+	RegisterCatalogServiceServer(srv, &Server{DAO: dao})
+	return srv
+}
+
+// ListBooks .
+func (s *Server) ListBooks(ctx context.Context, req *ListBooksRequest) (*ListBooksResponse, error) {
+	limit, offset, err := paginate(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, relation := range req.Include {
+		if !model.IsRelation(relation) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid include value: %s", relation)
+		}
+	}
+
+	books, err := s.DAO.Get(ctx, "books", nil, nil, "", limit, offset)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	result := model.ToBooks(books)
+	if err := s.DAO.LoadRelations(ctx, result, req.Include...); err != nil {
+		return nil, queryError(err)
+	}
+
+	return &ListBooksResponse{Metadata: metadataOf(limit, offset), Books: toBooks(result)}, nil
+}
+
+// StreamBooks sends every book from the requested page onwards, fetching
+// per_page books at a time.
+func (s *Server) StreamBooks(req *ListBooksRequest, stream grpc.ServerStreamingServer[Book]) error {
+	ctx := stream.Context()
+	limit, offset, err := paginate(req.Page, req.PerPage)
+	if err != nil {
+		return err
+	}
+
+	for _, relation := range req.Include {
+		if !model.IsRelation(relation) {
+			return status.Errorf(codes.InvalidArgument, "invalid include value: %s", relation)
+		}
+	}
+
+	for ; ; offset += limit {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		books, err := s.DAO.Get(ctx, "books", nil, nil, "", limit, offset)
+		if err != nil {
+			return queryError(err)
+		}
+		if len(books) == 0 {
+			return nil
+		}
+
+		result := model.ToBooks(books)
+		if err := s.DAO.LoadRelations(ctx, result, req.Include...); err != nil {
+			return queryError(err)
+		}
+
+		for i := range result {
+			if err := stream.Send(toBook(&result[i])); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetBook .
+func (s *Server) GetBook(ctx context.Context, req *GetBookRequest) (*Book, error) {
+	books, err := s.DAO.Get(ctx, "books", nil, []string{fmt.Sprintf("id = %d", req.Id)}, "", 1, 0)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	if len(books) == 0 {
+		return nil, status.Error(codes.NotFound, "no corresponding data found")
+	}
+
+	result := model.ToBooks(books)
+	if err := s.DAO.LoadRelations(ctx, result, model.Relations...); err != nil {
+		return nil, queryError(err)
+	}
+
+	return toBook(&result[0]), nil
+}
+
+// SearchBooks .
+func (s *Server) SearchBooks(ctx context.Context, req *SearchBooksRequest) (*ListBooksResponse, error) {
+	if req.Query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+
+	limit, offset, err := paginate(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := s.DAO.Search(ctx, req.Query, limit, offset)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	return &ListBooksResponse{Metadata: metadataOf(limit, offset), Books: toBooks(model.ToBooks(books))}, nil
+}
+
+// ListItems .
+func (s *Server) ListItems(ctx context.Context, req *ListItemsRequest) (*ListItemsResponse, error) {
+	entity, err := entityOf(req.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset, err := paginate(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.DAO.GetDistinctItems(ctx, entity, limit, offset)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	var result []*Item
+	for _, item := range model.ToItems(items) {
+		result = append(result, toItem(&item))
+	}
+
+	return &ListItemsResponse{Metadata: metadataOf(limit, offset), Items: result}, nil
+}
+
+// GetItem .
+func (s *Server) GetItem(ctx context.Context, req *GetItemRequest) (*Item, error) {
+	entity, err := entityOf(req.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset, err := paginate(req.Page, req.PerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.DAO.GetItemByID(ctx, entity, fmt.Sprint(req.Id), nil, limit, offset)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	return toItem(item), nil
+}
+
+// queryError reports a failed query, mapping the domain errors of the DAO
+// and keeping deadline and cancellation errors distinguishable from database
+// failures.
+func queryError(err error) error {
+	switch {
+	case errors.Is(err, model.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		return status.Error(codes.NotFound, "no corresponding data found")
+	case errors.Is(err, model.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, "database query failure")
+}
+
+func paginate(page, perPage int32) (limit int, offset int, err error) {
+	if page == 0 {
+		page = 1
+	}
+	if perPage == 0 {
+		perPage = 20
+	}
+	if page < 0 || perPage < 0 {
+		return -1, -1, status.Error(codes.InvalidArgument, "page and per_page must be positive")
+	}
+	return int(perPage), int(page-1) * int(perPage), nil
+}
+
+func metadataOf(limit, offset int) *Metadata {
+	page := offset/limit + 1
+	prev := 0
+	if page-1 > 0 {
+		prev = page - 1
+	}
+	return &Metadata{Page: int32(page), PerPage: int32(limit), Next: int32(page + 1), Prev: int32(prev)}
+}
+
+func entityOf(kind ItemKind) (string, error) {
+	switch kind {
+	case ItemKind_AUTHOR:
+		return "authors", nil
+	case ItemKind_CATEGORY:
+		return "categories", nil
+	case ItemKind_TAG:
+		return "tags", nil
+	}
+	return "", status.Error(codes.InvalidArgument, "kind is required")
+}
+
+func toBooks(books []model.Book) (result []*Book) {
+	for i := range books {
+		result = append(result, toBook(&books[i]))
+	}
+	return
+}
+
+func toBook(book *model.Book) *Book {
+	return &Book{
+		Id:          int64(book.ID),
+		Title:       book.Title,
+		ImageUrl:    book.ImageURL,
+		GramedUrl:   book.GramedURL,
+		Description: book.Description,
+		Authors:     toItems(book.Authors),
+		Categories:  toItems(book.Categories),
+		Tags:        toItems(book.Tags),
+	}
+}
+
+func toItems(items []model.Item) (result []*Item) {
+	for i := range items {
+		result = append(result, toItem(&items[i]))
+	}
+	return
+}
+
+func toItem(item *model.Item) *Item {
+	return &Item{
+		Id:    int64(item.ID),
+		Name:  item.Name,
+		Books: toBooks(item.Books),
+	}
+}
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/kautsarady/adindopustaka/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	_ "modernc.org/sqlite"
+)
+
+// catalogFixture serves CatalogService over three books, the first written
+// by ann, returning a client of it.
+func catalogFixture(t *testing.T) CatalogServiceClient {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	for _, q := range []string{
+		"CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, image_url TEXT, gramed_url TEXT, description TEXT)",
+		"CREATE TABLE authors (id INTEGER, book_id INTEGER, name TEXT)",
+		"CREATE TABLE categories (id INTEGER, book_id INTEGER, name TEXT)",
+		"CREATE TABLE tags (id INTEGER, book_id INTEGER, name TEXT)",
+		"INSERT INTO books VALUES (1, 'One', '', '', 'first'), (2, 'Two', '', '', 'second'), (3, 'Three', '', '', 'third')",
+		"INSERT INTO authors VALUES (7, 1, 'ann')",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(q, err)
+		}
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(&model.DAO{DB: db})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewCatalogServiceClient(conn)
+}
+
+func TestListBooks(t *testing.T) {
+	client := catalogFixture(t)
+	ctx := context.Background()
+
+	res, err := client.ListBooks(ctx, &ListBooksRequest{Page: 2, PerPage: 2, Include: []string{"authors"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Books) != 1 || res.Books[0].Title != "Three" || res.Metadata.Page != 2 || res.Metadata.Prev != 1 {
+		t.Errorf("ListBooks page 2 = %v", res)
+	}
+
+	res, err = client.ListBooks(ctx, &ListBooksRequest{Include: []string{"authors"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Books) != 3 || len(res.Books[0].Authors) != 1 || res.Books[0].Authors[0].Name != "Ann" {
+		t.Errorf("ListBooks with authors = %v", res)
+	}
+
+	for _, req := range []*ListBooksRequest{{Include: []string{"publishers"}}, {Page: -1}} {
+		if _, err := client.ListBooks(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListBooks(%v) = %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestStreamBooks(t *testing.T) {
+	client := catalogFixture(t)
+
+	stream, err := client.StreamBooks(context.Background(), &ListBooksRequest{PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for {
+		book, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, book.Title)
+	}
+	if len(titles) != 3 || titles[2] != "Three" {
+		t.Errorf("StreamBooks = %v, want every book across the pages", titles)
+	}
+}
+
+func TestGetBook(t *testing.T) {
+	client := catalogFixture(t)
+	ctx := context.Background()
+
+	book, err := client.GetBook(ctx, &GetBookRequest{Id: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.Title != "One" || len(book.Authors) != 1 {
+		t.Errorf("GetBook(1) = %v, want One by Ann", book)
+	}
+	if _, err := client.GetBook(ctx, &GetBookRequest{Id: 42}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetBook(42) = %v, want NotFound", err)
+	}
+}
+
+func TestSearchBooks(t *testing.T) {
+	client := catalogFixture(t)
+	ctx := context.Background()
+
+	res, err := client.SearchBooks(ctx, &SearchBooksRequest{Query: "seco"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Books) != 1 || res.Books[0].Id != 2 {
+		t.Errorf("SearchBooks(seco) = %v, want Two", res)
+	}
+	if _, err := client.SearchBooks(ctx, &SearchBooksRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SearchBooks without a query = %v, want InvalidArgument", err)
+	}
+}
+
+func TestItems(t *testing.T) {
+	client := catalogFixture(t)
+	ctx := context.Background()
+
+	res, err := client.ListItems(ctx, &ListItemsRequest{Kind: ItemKind_AUTHOR})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 1 || res.Items[0].Id != 7 {
+		t.Errorf("ListItems(AUTHOR) = %v, want ann", res)
+	}
+
+	item, err := client.GetItem(ctx, &GetItemRequest{Kind: ItemKind_AUTHOR, Id: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(item.Books) != 1 || item.Books[0].Title != "One" {
+		t.Errorf("GetItem(AUTHOR, 7) = %v, want the books of ann", item)
+	}
+
+	if _, err := client.GetItem(ctx, &GetItemRequest{Kind: ItemKind_TAG, Id: 7}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetItem(TAG, 7) = %v, want NotFound", err)
+	}
+	if _, err := client.ListItems(ctx, &ListItemsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListItems without a kind = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/kautsarady/adindopustaka/config"
+	"github.com/kautsarady/adindopustaka/health"
+	"google.golang.org/grpc"
+)
+
+// serve runs the HTTP server, and the gRPC server when there is one, until
+// either fails or ctx is done. It then drains them: readiness turns off for
+// server.drain_delay so that load balancers stop routing here, then in-flight
+// requests get up to server.drain_timeout to finish.
+func serve(ctx context.Context, cfg *config.Config, handler http.Handler, grpcSrv *grpc.Server, checker *health.Checker) error {
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	tlsEnabled := cfg.Server.TLSCertFile != ""
+	if tlsEnabled {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if !cfg.Server.HTTP2 {
+			// a non nil map keeps net/http from negotiating h2
+			srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+	}
+
+	var lis net.Listener
+	if grpcSrv != nil {
+		var err error
+		if lis, err = net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.GRPCPort)); err != nil {
+			return err
+		}
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		var err error
+		if tlsEnabled {
+			err = srv.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
+			errs <- err
+		}
+	}()
+	if grpcSrv != nil {
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "drain_delay", cfg.Server.DrainDelay.String())
+	checker.Drain()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	drain, cancel := context.WithTimeout(context.Background(), cfg.Server.DrainTimeout)
+	defer cancel()
+
+	if grpcSrv != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		defer func() {
+			select {
+			case <-stopped:
+			case <-drain.Done():
+				grpcSrv.Stop()
+			}
+		}()
+	}
+
+	if err := srv.Shutdown(drain); err != nil {
+		return fmt.Errorf("requests still running after %s: %v", cfg.Server.DrainTimeout, err)
+	}
+	return nil
+}
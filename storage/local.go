@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Local stores blobs as the files of Dir, which is created on the first
+// write.
+type Local struct {
+	Dir string
+}
+
+// path keeps name inside Dir.
+func (l Local) path(name string) string {
+	return filepath.Join(l.Dir, filepath.Base(name))
+}
+
+// Put .
+func (l Local) Put(_ context.Context, name string, r io.Reader, _ int64, _ string) error {
+	if err := os.MkdirAll(l.Dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(l.Dir, filepath.Base(name)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path(name)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Open .
+func (l Local) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(l.path(name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+// Delete .
+func (l Local) Delete(_ context.Context, name string) error {
+	if err := os.Remove(l.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "covers")
+	l := Local{Dir: dir}
+
+	if _, err := l.Open(ctx, "1-cafe.jpg"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Open before Put = %v, want ErrNotExist", err)
+	}
+
+	for _, content := range []string{"first", "second"} {
+		if err := l.Put(ctx, "1-cafe.jpg", strings.NewReader(content), int64(len(content)), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+		r, err := l.Open(ctx, "1-cafe.jpg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("Open = %q, want %q", got, content)
+		}
+	}
+
+	// no temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in %s, want 1", len(entries), dir)
+	}
+
+	if err := l.Delete(ctx, "1-cafe.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Open(ctx, "1-cafe.jpg"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Open after Delete = %v, want ErrNotExist", err)
+	}
+	if err := l.Delete(ctx, "1-cafe.jpg"); err != nil {
+		t.Errorf("Delete of a missing blob = %v", err)
+	}
+}
+
+func TestLocalKeepsNamesInDir(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	l := Local{Dir: filepath.Join(root, "covers")}
+
+	if err := l.Put(ctx, "../escaped.jpg", strings.NewReader("x"), 1, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped.jpg")); !os.IsNotExist(err) {
+		t.Errorf("Put wrote outside of Dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(l.Dir, "escaped.jpg")); err != nil {
+		t.Errorf("Put did not write into Dir: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 serves the path style object requests of a single bucket from
+// memory, which is as much of S3 as S3 uses.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, name := r.URL.Path[1:], ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, name = bucket[:i], bucket[i+1:]
+	}
+	if bucket != f.bucket {
+		s3Error(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if name == "" {
+		// BucketExists
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil && strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			body, err = unchunk(body)
+		}
+		if err != nil {
+			s3Error(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[name] = body
+		f.types[name] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[name]
+		if !ok {
+			s3Error(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", f.types[name])
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(body))
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// unchunk decodes an aws-chunked body, the signed chunks sent over plain
+// HTTP, ignoring their signatures.
+func unchunk(body []byte) ([]byte, error) {
+	var out []byte
+	for {
+		i := bytes.Index(body, []byte("\r\n"))
+		if i < 0 {
+			return nil, errors.New("truncated chunk header")
+		}
+		size, err := strconv.ParseInt(strings.SplitN(string(body[:i]), ";", 2)[0], 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		body = body[i+2:]
+		if size == 0 {
+			return out, nil
+		}
+		if int64(len(body)) < size+2 {
+			return nil, errors.New("truncated chunk")
+		}
+		out = append(out, body[:size]...)
+		body = body[size+2:]
+	}
+}
+
+func s3Error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>` + code + `</Code><Message>` + code + `</Message></Error>`))
+	}
+}
+
+func newFakeS3(t *testing.T) (*S3, *fakeS3) {
+	fake := &fakeS3{bucket: "covers", objects: make(map[string][]byte), types: make(map[string]string)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewS3(S3Options{Endpoint: u.Host, Region: "us-east-1", Bucket: "covers", AccessKey: "key", SecretKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, fake
+}
+
+func TestS3(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newFakeS3(t)
+
+	if err := s.Ping(ctx); err != nil {
+		t.Fatalf("Ping = %v", err)
+	}
+	if _, err := s.Open(ctx, "1-cafe.jpg"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Open before Put = %v, want ErrNotExist", err)
+	}
+
+	if err := s.Put(ctx, "1-cafe.jpg", strings.NewReader("cover"), 5, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.types["1-cafe.jpg"]; got != "image/jpeg" {
+		t.Errorf("stored content type = %q", got)
+	}
+	r, err := s.Open(ctx, "1-cafe.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "cover" {
+		t.Errorf("Open = %q, want %q", got, "cover")
+	}
+
+	if err := s.Delete(ctx, "1-cafe.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, "1-cafe.jpg"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Open after Delete = %v, want ErrNotExist", err)
+	}
+}
+
+func TestS3PingMissingBucket(t *testing.T) {
+	s, fake := newFakeS3(t)
+	fake.bucket = "other"
+	if err := s.Ping(context.Background()); err == nil {
+		t.Error("Ping of a missing bucket succeeded")
+	}
+}
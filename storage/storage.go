@@ -0,0 +1,23 @@
+// Package storage keeps uploaded files, such as book covers, on the local
+// disk or in an S3 compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotExist is returned when no blob is stored under the requested name.
+var ErrNotExist = errors.New("storage: blob does not exist")
+
+// Store keeps blobs under flat names, without directories.
+type Store interface {
+	// Put stores the size bytes of r under name, replacing any blob of that
+	// name.
+	Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error
+	// Open returns the content of the blob stored under name.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// Delete removes the blob stored under name, if any.
+	Delete(ctx context.Context, name string) error
+}
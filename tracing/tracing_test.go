@@ -0,0 +1,161 @@
+package tracing_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kautsarady/adindopustaka/model"
+	"github.com/kautsarady/adindopustaka/tracing"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite"
+)
+
+// receiver is an OTLP/HTTP collector keeping the spans it is sent.
+type receiver struct {
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/traces" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rc.mu.Lock()
+	for _, resource := range req.ResourceSpans {
+		for _, scope := range resource.ScopeSpans {
+			rc.spans = append(rc.spans, scope.Spans...)
+		}
+	}
+	rc.mu.Unlock()
+
+	out, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(out)
+}
+
+func (rc *receiver) span(name string) *tracepb.Span {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, s := range rc.spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func attributes(s *tracepb.Span) map[string]*commonpb.AnyValue {
+	attrs := make(map[string]*commonpb.AnyValue)
+	for _, kv := range s.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestExport(t *testing.T) {
+	rc := &receiver{}
+	collector := httptest.NewServer(rc)
+	defer collector.Close()
+
+	ctx := context.Background()
+	shutdown, err := tracing.Setup(ctx, tracing.Options{Endpoint: collector.URL, ServiceName: "test", SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	for _, q := range []string{
+		"CREATE TABLE tags (id INTEGER, book_id INTEGER, name TEXT)",
+		"INSERT INTO tags VALUES (30, 1, 'new'), (30, 2, 'new'), (31, 3, 'old')",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dao := &model.DAO{DB: db}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing.Middleware())
+	router.GET("/tag/:id", func(ctx *gin.Context) {
+		if _, err := dao.Get(ctx.Request.Context(), "tags", nil, []string{"id = " + ctx.Param("id")}, "", 10, 0); err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		ctx.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/tag/30", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /tag/30 = %d", w.Code)
+	}
+
+	if err := shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	server := rc.span("GET /tag/:id")
+	if server == nil {
+		t.Fatalf("no server span in %v", rc.spans)
+	}
+	if server.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("server span kind = %v", server.Kind)
+	}
+	attrs := attributes(server)
+	if got := attrs["http.route"].GetStringValue(); got != "/tag/:id" {
+		t.Errorf("http.route = %q", got)
+	}
+	if got := attrs["http.response.status_code"].GetIntValue(); got != http.StatusOK {
+		t.Errorf("http.response.status_code = %d", got)
+	}
+	// the caller's trace continues
+	if got := hex.EncodeToString(server.TraceId); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace id = %s", got)
+	}
+
+	query := rc.span("get tags")
+	if query == nil {
+		t.Fatalf("no DAO span in %v", rc.spans)
+	}
+	if string(query.ParentSpanId) != string(server.SpanId) {
+		t.Errorf("DAO span parent = %x, want %x", query.ParentSpanId, server.SpanId)
+	}
+	attrs = attributes(query)
+	for key, want := range map[string]string{
+		"db.collection.name": "tags",
+		"db.operation.name":  "get",
+		"db.query.filter":    "id = ?",
+	} {
+		if got := attrs[key].GetStringValue(); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if got := attrs["db.rows"].GetIntValue(); got != 2 {
+		t.Errorf("db.rows = %d, want 2", got)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kautsarady/adindopustaka/auth"
+	"github.com/kautsarady/adindopustaka/model"
+)
+
+const usersUsage = `usage: adindopustaka users create -username NAME -role editor|admin
+       adindopustaka users revoke -username NAME
+
+create reads the password from the first line of stdin, revoke signs the
+user out of every console session`
+
+// users manages the admin console accounts.
+func users(ctx context.Context, dao *model.DAO, args []string) error {
+	if len(args) > 0 && args[0] == "revoke" {
+		return revokeUser(ctx, dao, args[1:])
+	}
+	if len(args) == 0 || args[0] != "create" {
+		return errors.New(usersUsage)
+	}
+
+	fs := flag.NewFlagSet("users create", flag.ContinueOnError)
+	username := fs.String("username", "", "login name")
+	role := fs.String("role", auth.RoleEditor, "editor or admin")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("users create: -username is required")
+	}
+	if *role != auth.RoleEditor && *role != auth.RoleAdmin {
+		return fmt.Errorf("users create: unknown role %q", *role)
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return errors.New("users create: no password on stdin")
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if len(password) < 8 {
+		return errors.New("users create: password must be at least 8 characters")
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := dao.CreateUserTable(ctx); err != nil {
+		return err
+	}
+	user := &model.User{Username: *username, PasswordHash: hash, Role: *role}
+	if err := dao.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	fmt.Printf("created %s %s\n", user.Role, user.Username)
+	return nil
+}
+
+// revokeUser ends every console session of a user.
+func revokeUser(ctx context.Context, dao *model.DAO, args []string) error {
+	fs := flag.NewFlagSet("users revoke", flag.ContinueOnError)
+	username := fs.String("username", "", "login name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("users revoke: -username is required")
+	}
+
+	if err := dao.CreateSessionTable(ctx); err != nil {
+		return err
+	}
+	n, err := dao.DeleteUserSessions(ctx, *username)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ended %d sessions of %s\n", n, *username)
+	return nil
+}
@@ -0,0 +1,66 @@
+// Package web holds the templates and static assets of the pages, embedded
+// in the binary.
+//
+// Every file of templates/pages is a page named after the file. The pages
+// define a content template, and those whose name starts with admin_ a title
+// template too, which the layout of templates/layouts wraps: admin.html for
+// the admin console and site.html for the others. The templates of
+// templates/partials are shared by every page.
+package web
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin/render"
+)
+
+//go:embed templates static
+var files embed.FS
+
+// Renderer renders the pages for gin, see gin.Engine.HTMLRender.
+type Renderer struct {
+	pages map[string]*template.Template
+}
+
+// Load parses every page along with its layout and the partials. funcs are
+// available to all of them.
+func Load(funcs template.FuncMap) (*Renderer, error) {
+	names, err := fs.Glob(files, "templates/pages/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Renderer{pages: make(map[string]*template.Template)}
+	for _, name := range names {
+		page := path.Base(name)
+		layout := "templates/layouts/site.html"
+		if strings.HasPrefix(page, "admin_") {
+			layout = "templates/layouts/admin.html"
+		}
+		t, err := template.New(page).Funcs(funcs).ParseFS(files, layout, "templates/partials/*.html", name)
+		if err != nil {
+			return nil, err
+		}
+		r.pages[page] = t
+	}
+	return r, nil
+}
+
+// Instance .
+func (r *Renderer) Instance(name string, data interface{}) render.Render {
+	return render.HTML{Template: r.pages[name], Name: "layout", Data: data}
+}
+
+// Static returns the stylesheets, served under /static/.
+func Static() http.FileSystem {
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FS(static)
+}
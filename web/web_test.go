@@ -0,0 +1,48 @@
+package web
+
+import (
+	"html/template"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	r, err := Load(template.FuncMap{"thumbnail": func(int, string, int) string { return "" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ page, stylesheet string }{
+		{"admin_login.html", "/static/admin.css"},
+		{"index.html", "/static/site.css"},
+		{"detail.html", "/static/site.css"},
+	} {
+		w := httptest.NewRecorder()
+		if err := r.Instance(tt.page, map[string]interface{}{}).Render(w); err != nil {
+			t.Errorf("render %s: %v", tt.page, err)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), tt.stylesheet) {
+			t.Errorf("%s lacks the layout linking %s", tt.page, tt.stylesheet)
+		}
+	}
+}
+
+func TestStatic(t *testing.T) {
+	for _, name := range []string{"/site.css", "/admin.css"} {
+		f, err := Static().Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(f)
+		f.Close()
+		if err != nil || len(b) == 0 {
+			t.Errorf("%s = %d bytes, %v", name, len(b), err)
+		}
+	}
+	if _, err := Static().Open("/../templates/layouts/site.html"); err == nil {
+		t.Error("Static serves the templates")
+	}
+}